@@ -0,0 +1,247 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// BinaryOption A native executable that is downloaded and run directly
+// instead of in a container
+type BinaryOption struct {
+	// URL Download location, a template which can use
+	// {{.Version}}, {{.OS}} and {{.Arch}}
+	URL string
+
+	// SHA256 Checksum of the download, keyed by os/arch
+	SHA256 map[string]string `yaml:"sha256"`
+
+	// Path of the executable inside a .zip or .tar.gz download.
+	// Defaults to the command name.
+	Path string `yaml:",omitempty"`
+}
+
+func platformName() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+func binaryURL(cmd RepoCommand) (string, error) {
+	t, err := template.New("url").Parse(cmd.Binary.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, struct{ Version, OS, Arch string }{
+		Version: parseCommand(cmd.Name).version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+	})
+	return b.String(), err
+}
+
+func binaryFileName(cmd RepoCommand) string {
+	if cmd.Binary.Path > "" {
+		return path.Base(cmd.Binary.Path)
+	}
+	return parseCommand(cmd.Name).command
+}
+
+func getBinaryPath(cmd RepoCommand) (string, error) {
+	return getClicBinariesPath(cmd.Name, binaryFileName(cmd))
+}
+
+func binaryInstalled(cmd RepoCommand) bool {
+	p, err := getBinaryPath(cmd)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+// removeBinary Deletes the downloaded executable of a binary command
+func removeBinary(cmd RepoCommand) error {
+	if cmd.Binary == nil {
+		return nil
+	}
+	dir, err := getClicBinariesPath(cmd.Name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// installBinary Downloads, verifies and caches the executable
+func installBinary(cmd RepoCommand) error {
+	expected := cmd.Binary.SHA256[platformName()]
+	if expected == "" {
+		return fmt.Errorf("No checksum for %s on %s", cmd.Name, platformName())
+	}
+
+	url, err := binaryURL(cmd)
+	if err != nil {
+		return err
+	}
+
+	dst, err := getBinaryPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	download, err := ioutil.TempFile(filepath.Dir(dst), "download-")
+	if err != nil {
+		return err
+	}
+	download.Close()
+	defer os.Remove(download.Name())
+
+	err = downloadFile(download.Name(), url)
+	if err != nil {
+		return err
+	}
+
+	actual, err := sha256File(download.Name())
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("Checksum mismatch for %s: expected %s but got %s", url, expected, actual)
+	}
+
+	tmp := dst + ".tmp"
+	defer os.Remove(tmp)
+
+	inner := cmd.Binary.Path
+	if inner == "" {
+		inner = binaryFileName(cmd)
+	}
+
+	switch {
+	case strings.HasSuffix(url, ".zip"):
+		err = extractZip(download.Name(), inner, tmp)
+	case strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz"):
+		err = extractTarGz(download.Name(), inner, tmp)
+	default:
+		err = copyFile(download.Name(), tmp)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp, 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+func sha256File(f string) (string, error) {
+	in, err := os.Open(f)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return writeFile(dst, in)
+}
+
+func writeFile(dst string, r io.Reader) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, r)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func extractZip(archive, inner, dst string) error {
+	z, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if path.Clean(f.Name) != path.Clean(inner) {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		return writeFile(dst, r)
+	}
+
+	return fmt.Errorf("%s not found in download", inner)
+}
+
+func extractTarGz(archive, inner, dst string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	t := tar.NewReader(gz)
+	for {
+		h, err := t.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if h.Typeflag == tar.TypeReg && path.Clean(h.Name) == path.Clean(inner) {
+			return writeFile(dst, t)
+		}
+	}
+
+	return fmt.Errorf("%s not found in download", inner)
+}
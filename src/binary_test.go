@@ -0,0 +1,101 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func withTempHome(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "clic-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Getenv("HOME")
+	os.Setenv("HOME", dir)
+	return func() {
+		os.Setenv("HOME", old)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestInstallBinary(t *testing.T) {
+	defer withTempHome(t)()
+
+	archive := tarGz(t, "linux-amd64/tool", []byte("#!/bin/sh\necho hi\n"))
+	sum := sha256.Sum256(archive)
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	cmd := RepoCommand{
+		Name: "tool@1.2.3",
+		Binary: &BinaryOption{
+			URL:    server.URL + "/tool_{{.Version}}.tar.gz",
+			SHA256: map[string]string{platformName(): hex.EncodeToString(sum[:])},
+			Path:   "linux-amd64/tool",
+		},
+	}
+
+	assertEqual(t, false, binaryInstalled(cmd))
+	assertEqual(t, nil, installBinary(cmd))
+	assertEqual(t, "/tool_1.2.3.tar.gz", requested)
+	assertEqual(t, true, binaryInstalled(cmd))
+
+	p, _ := getBinaryPath(cmd)
+	info, err := os.Stat(p)
+	assertEqual(t, nil, err)
+	assertEqual(t, os.FileMode(0755), info.Mode().Perm())
+
+	cmds, err := BuildCommands(cmd, []string{"--version"}, BuildOptions{})
+	assertEqual(t, nil, err)
+	assertEqual(t, 1, len(cmds))
+	assertEqual(t, p, cmds[0].Name)
+	assertEqual(t, "--version", cmds[0].Args[0])
+
+	// As done on uninstall and when a newer version replaces it
+	assertEqual(t, nil, removeBinary(cmd))
+	assertEqual(t, false, binaryInstalled(cmd))
+}
+
+func TestInstallBinaryChecksumMismatch(t *testing.T) {
+	defer withTempHome(t)()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	}))
+	defer server.Close()
+
+	cmd := RepoCommand{
+		Name: "tool@1.2.3",
+		Binary: &BinaryOption{
+			URL:    server.URL + "/tool",
+			SHA256: map[string]string{platformName(): "0000"},
+		},
+	}
+
+	assertEqual(t, true, installBinary(cmd) != nil)
+	assertEqual(t, false, binaryInstalled(cmd))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LabelCache Marks a volume as a cache of the command in its value
+const LabelCache = "clic.cache"
+
+// CacheOption A container folder kept in a named volume between runs
+type CacheOption struct {
+	Path string
+	// PerVersion Keep a separate cache for each version of the command
+	PerVersion bool `yaml:"perVersion,omitempty"`
+}
+
+// UnmarshalYAML Also accepts just the path
+func (c *CacheOption) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*c = CacheOption{Path: s}
+		return nil
+	}
+
+	type plain CacheOption
+	return unmarshal((*plain)(c))
+}
+
+// cacheVolume A named volume clic manages for a cache
+type cacheVolume struct {
+	Name    string
+	Command string
+	Size    string
+}
+
+// cacheVolumeName Names the volume after the command, with the version
+// for a per version cache, and the container path
+func cacheVolumeName(cmd CommandVersion, path string) string {
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(path)))[:8]
+	return "clic-cache-" + containerSafeName(cmd.toString()) + "-" + hash
+}
+
+// determineCaches Returns the docker run flags that mount the cache volumes.
+// Docker creates a missing volume with the label when it is first mounted.
+func determineCaches(cmd RepoCommand, img string) ([]string, error) {
+	name := cmd.Name
+	if name == "" {
+		name = commandNameFromImage(img)
+	}
+
+	var flags []string
+	for _, c := range cmd.Caches {
+		if !strings.HasPrefix(c.Path, "/") {
+			return nil, fmt.Errorf("Cache path of %s must be absolute: %s", name, c.Path)
+		}
+
+		cmdVers := parseCommand(name)
+		if !c.PerVersion {
+			cmdVers = CommandVersion{command: cmdVers.command}
+		}
+
+		flags = append(flags, "--mount", fmt.Sprintf("type=volume,src=%s,dst=%s,volume-label=%s=%s",
+			cacheVolumeName(cmdVers, c.Path), c.Path, LabelCache, cmdVers.toString()))
+	}
+	return flags, nil
+}
+
+// listCacheVolumes Returns the cache volumes with their size
+func listCacheVolumes() ([]cacheVolume, error) {
+	format := fmt.Sprintf(`{{.Name}}\t{{.Label "%s"}}`, LabelCache)
+	out, err := exec.Command("docker", "volume", "ls", "--filter", "label="+LabelCache, "--format", format).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := volumeSizes()
+
+	var volumes []cacheVolume
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 2 {
+			continue
+		}
+		size := sizes[parts[0]]
+		if size == "" {
+			size = "-"
+		}
+		volumes = append(volumes, cacheVolume{Name: parts[0], Command: parts[1], Size: size})
+	}
+	return volumes, nil
+}
+
+// volumeSizes Reads the volume sizes from docker system df, which only
+// reports them in its verbose table
+func volumeSizes() map[string]string {
+	sizes := make(map[string]string)
+
+	out, err := exec.Command("docker", "system", "df", "-v").Output()
+	if err != nil {
+		return sizes
+	}
+
+	// VOLUME NAME   LINKS   SIZE
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "clic-cache-") {
+			sizes[fields[0]] = fields[2]
+		}
+	}
+	return sizes
+}
+
+// matches Whether the cache belongs to the given command or command@version
+func (v cacheVolume) matches(cmd CommandVersion) bool {
+	return clicContainer{Command: v.Command}.matches(cmd)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDetermineCaches(t *testing.T) {
+	var cmd RepoCommand
+	err := yaml.Unmarshal([]byte(`
+name: terraform@0.12.24
+caches:
+- /root/.terraform.d/plugin-cache
+- path: /root/.cache
+  perVersion: true
+`), &cmd)
+	assertEqual(t, nil, err)
+
+	flags, err := determineCaches(cmd, "hashicorp/terraform:0.12.24")
+	assertEqual(t, nil, err)
+	assertEqual(t, 4, len(flags))
+	assertEqual(t, "type=volume,src="+cacheVolumeName(parseCommand("terraform"), "/root/.terraform.d/plugin-cache")+
+		",dst=/root/.terraform.d/plugin-cache,volume-label=clic.cache=terraform", flags[1])
+	assertEqual(t, "type=volume,src="+cacheVolumeName(parseCommand("terraform@0.12.24"), "/root/.cache")+
+		",dst=/root/.cache,volume-label=clic.cache=terraform@0.12.24", flags[3])
+
+	assertEqual(t, true, cacheVolume{Command: "terraform@0.12.24"}.matches(parseCommand("terraform")))
+	assertEqual(t, false, cacheVolume{Command: "terraform"}.matches(parseCommand("terraform@0.12.24")))
+
+	cmd.Caches = []CacheOption{{Path: "cache"}}
+	_, err = determineCaches(cmd, "")
+	assertEqual(t, "Cache path of terraform@0.12.24 must be absolute: cache", err.Error())
+}
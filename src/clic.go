@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -12,12 +13,21 @@ func doHelp(_ []string) error {
 	fmt.Println("Usage: clic COMMAND [ARGS] ")
 	fmt.Println()
 	fmt.Println("Commands:")
+	fmt.Println("  add        Create a local command from the labels of an image")
+	fmt.Println("  bench      Measure the time clic adds before running a command")
+	fmt.Println("  cache      List or clear the cache volumes of a command")
+	fmt.Println("  du         Show the disk space used by commands")
 	fmt.Println("  explain    Show statements that will be executed when running a command")
 	fmt.Println("  install    Install command or clic itself")
 	fmt.Println("  fetch      Fetch latest command listing")
+	fmt.Println("  import     Create a local command from a docker run command line")
 	fmt.Println("  link       Create a shell alias")
+	fmt.Println("  logs       Show the output of a command running in the background")
 	fmt.Println("  ls         List installed commands")
+	fmt.Println("  open       Open the web UI of a running command")
+	fmt.Println("  ps         List running containers started by clic")
 	fmt.Println("  run        Run a command explicitly without a shell alias")
+	fmt.Println("  stop       Stop the running containers of a command")
 	fmt.Println("  uninstall  Uninstall command")
 	fmt.Println("  unlink     Delete a shell alias")
 	fmt.Println("  upgrade    Upgrade installed command to the latest version")
@@ -34,11 +44,9 @@ func main() {
 		// Use the incoming process
 		// name as the command to run.
 		processName := filepath.Base(os.Args[0])
-		runArgs := []string{processName}
-		runArgs = append(runArgs, os.Args[1:]...)
-		err := doRun(runArgs)
+		err := runShim(processName, os.Args[1:])
 		if err != nil {
-			fmt.Println(err)
+			exit(err)
 		}
 		return
 	}
@@ -49,12 +57,21 @@ func main() {
 	}
 
 	var commands = map[string]func([]string) error{
+		"add":       doAdd,
+		"bench":     doBench,
+		"cache":     doCache,
+		"du":        doDu,
 		"explain":   doExplain,
 		"fetch":     doFetch,
+		"import":    doImport,
 		"install":   doInstall,
 		"link":      doLink,
+		"logs":      doLogs,
 		"ls":        doList,
+		"open":      doOpen,
+		"ps":        doPs,
 		"run":       doRun,
+		"stop":      doStop,
 		"uninstall": doUninstall,
 		"unlink":    doUnlink,
 		"upgrade":   doUpgrade,
@@ -68,11 +85,26 @@ func main() {
 
 	err := f(os.Args[2:])
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(255)
+		exit(err)
 	}
 }
 
+// exit Exits with the code of a failed process, which has already reported
+// its own error, or prints the error and exits with 255
+func exit(err error) {
+	if exitError, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitError.ExitCode())
+	}
+
+	if _, ok := err.(*timeoutError); ok {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(timeoutExitCode)
+	}
+
+	fmt.Println(err)
+	os.Exit(255)
+}
+
 func isExecutedViaSymlink() bool {
 	name := filepath.Base(os.Args[0])
 	return name != "clic" && !strings.HasPrefix(name, "clic-")
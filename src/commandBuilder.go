@@ -2,10 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Command Command line to be executed
@@ -14,8 +18,53 @@ type Command struct {
 	Args      []string
 	Exit      bool
 	StdinFile string
+	StdinData string
 	Stdin     bool
+	Tty       bool
 	Skip      bool
+	Quiet     bool
+
+	// Container the command runs in
+	Container string
+
+	// EnvFile Environment for the container, read by docker from
+	// envFileDescriptor, its values are not displayed
+	EnvFile []string
+
+	// Secrets Written before the command runs and removed afterwards
+	Secrets []secretFile
+
+	// AgentProxy Runs alongside the command to relay the ssh agent
+	AgentProxy *agentProxy
+
+	// PathMap Container paths in the output are rewritten to these host paths
+	PathMap []pathMapping
+
+	// Retry Run again until it succeeds, for at most this long
+	Retry time.Duration
+
+	// Cleanup Run after the command, also when it fails
+	Cleanup []Command
+
+	// Detached Recorded in the state once the container is started
+	Detached *DetachedContainer
+
+	// Ports Published on the host, their URLs are printed when the command starts
+	Ports []publishedPort
+
+	// Stderr Where the error output goes instead of os.Stderr
+	Stderr io.Writer
+
+	// Shared Starts a container that concurrent invocations share, any
+	// of them may start it
+	Shared bool
+
+	// Timeout After which the container is stopped
+	Timeout time.Duration
+
+	// Supervise Keep clic running as the parent to forward signals
+	// to the container and clean it up
+	Supervise bool
 }
 
 // Display Prints the command to the console in a straight forward way where it
@@ -30,20 +79,308 @@ func (c *Command) Display() {
 	if c.StdinFile > "" {
 		stdIn = " < " + c.StdinFile
 	}
-	fmt.Println(skipped + c.Name + " " + strings.Join(c.Args, " ") + stdIn)
+	if c.StdinData > "" {
+		stdIn = " <<'EOF'\n" + c.StdinData + "EOF"
+	}
+	var args []string
+	for _, a := range c.Args {
+		args = append(args, shellQuote(a))
+	}
+	envFile := ""
+	if len(c.EnvFile) > 0 {
+		envFile = " 3<<'EOF'\n"
+		for _, n := range envNames(c.EnvFile) {
+			envFile += n + "=<redacted>\n"
+		}
+		envFile += "EOF"
+	}
+
+	quiet := ""
+	if c.Quiet {
+		quiet = " > /dev/null"
+	}
+	line := c.Name + " " + strings.Join(args, " ") + quiet + stdIn + envFile
+	if c.Retry > 0 {
+		line = fmt.Sprintf("until %s 2>&1; do sleep 1; done # at most %v", line, c.Retry)
+	}
+	fmt.Println(skipped + line)
+}
+
+func (c *Command) stderr() io.Writer {
+	if c.Stderr != nil {
+		return c.Stderr
+	}
+	return os.Stderr
+}
+
+// shellQuote Quotes the argument if needed so it can be pasted into a shell
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(s, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// BuildOptions How this invocation runs, as opposed to what the command is
+type BuildOptions struct {
+	// Detach Run in the background
+	Detach bool
 }
 
 // BuildCommands Turn given repo command and args into the raw command lines to be executed
-func BuildCommands(cmd RepoCommand, args []string) []Command {
+func BuildCommands(cmd RepoCommand, args []string, build BuildOptions) ([]Command, error) {
 	var cmds []Command
 
-	img := cmd.Image
+	if cmd.Binary != nil {
+		if build.Detach {
+			return nil, fmt.Errorf("Binary command %s can not run in the background", cmd.Name)
+		}
+		bin, err := getBinaryPath(cmd)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, Command{
+			Name:  bin,
+			Args:  args,
+			Exit:  true,
+			Stdin: true})
+		return cmds, nil
+	}
+
+	img, buildCmd, err := determineImage(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if buildCmd != nil {
+		cmds = append(cmds, *buildCmd)
+	}
+
+	volumes, workdir := determineVolumes(cmd)
+	configVolumes, err := determineConfigVolumes(cmd)
+	if err != nil {
+		return nil, err
+	}
+	volumes = append(volumes, configVolumes...)
+
+	stdin, err := determineStdInEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+	tty, err := determineTtyEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if build.Detach {
+		// Nobody is there to type or look
+		stdin, tty = false, false
+	}
+	envs := determinEnvVars(cmd)
+	cmdEnv, err := determineEnv(cmd)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cmdEnv.Fixed {
+		envs[k] = v
+	}
+
+	if cmd.PathArgs.Enabled {
+		if cwd, err := os.Getwd(); err == nil {
+			var pathVolumes []string
+			args, pathVolumes = rewritePathArgs(cmd.PathArgs, args, volumes, cwd)
+			volumes = append(volumes, pathVolumes...)
+		}
+	}
+
+	entrypoint := cmd.Entrypoint
+	if cmd.Script > "" {
+		// Entrypoint is the shell that runs the script
+		if entrypoint == "" {
+			entrypoint = "/bin/sh"
+		}
+		args = append([]string{"-c", cmd.Script, cmd.Name}, args...)
+	}
+
+	dockerVolumes, dockerEnv, groups, err := determineDocker(cmd)
+	if err != nil {
+		return nil, err
+	}
+	volumes = append(volumes, dockerVolumes...)
+	for k, v := range dockerEnv {
+		envs[k] = v
+	}
+
+	sandbox, err := determineSandbox(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(cmd.Ports) > 0 && hasFlag(sandbox, "--network", "none") {
+		return nil, fmt.Errorf("Ports can not be published for %s, its sandbox has no network", cmd.Name)
+	}
+	timeout, err := determineTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+	caches, err := determineCaches(cmd, img)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := dockerRunOptions{
+		Image:      img,
+		Volumes:    volumes,
+		Workdir:    workdir,
+		Entrypoint: entrypoint,
+		Args:       args,
+		Stdin:      stdin,
+		Tty:        tty,
+		User:       cmd.User,
+		Groups:     groups,
+		Init:       cmd.Init,
+		Sandbox:    sandbox,
+		Resources:  determineResources(cmd),
+		Mounts:     caches,
+		Env:        envs,
+		PassEnv:    cmdEnv.Pass,
+		EnvFile:    len(cmdEnv.File) > 0,
+	}
 
+	if cmd.Persistent {
+		if build.Detach {
+			return nil, fmt.Errorf("Persistent command %s can not run in the background", cmd.Name)
+		}
+		if len(cmd.Services) > 0 {
+			return nil, fmt.Errorf("Services are not supported for persistent command %s", cmd.Name)
+		}
+		if len(cmd.Secrets) > 0 || cmd.SSH > "" || cmd.MapOutput || timeout > 0 || len(cmd.Ports) > 0 {
+			return nil, fmt.Errorf("Secrets, ssh, mapOutput, timeout and ports are not supported for persistent command %s", cmd.Name)
+		}
+		persistentCmds, err := buildPersistentCommands(cmd, opts, cmdEnv.File)
+		if err != nil {
+			return nil, err
+		}
+		return append(cmds, persistentCmds...), nil
+	}
+
+	// Name and label the container so it can be
+	// signalled and cleaned up
+	opts.Name = runContainerName(cmd, img)
+	opts.Labels = runContainerLabels(cmd, img)
+	if build.Detach {
+		// Not tied to this clic process, and outliving its pid
+		opts.Name, err = detachedContainerName(cmd, img)
+		if err != nil {
+			return nil, err
+		}
+		opts.Labels[LabelMode] = ModeDetached
+		delete(opts.Labels, LabelPid)
+		delete(opts.Labels, LabelHost)
+		delete(opts.Labels, LabelStarted)
+		opts.Detach = true
+	}
+
+	var services, teardown []Command
+	if len(cmd.Services) > 0 {
+		if build.Detach {
+			return nil, fmt.Errorf("Services are not supported in the background for %s", cmd.Name)
+		}
+
+		// The network is named and labeled like the container
+		internal := removeFlag(&opts.Sandbox, "--network", "none")
+		opts.Network = opts.Name
+		services, teardown, err = determineServices(cmd, opts.Network, opts.Labels, internal)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, services...)
+	}
+
+	ports, err := determinePorts(cmd)
+	if err != nil {
+		return nil, err
+	}
+	opts.Ports = publishFlags(ports)
+
+	// Piping the output of an interactive terminal session would
+	// take the window size and its changes away from docker
+	var pathMap []pathMapping
+	if cmd.MapOutput && !(tty && isTerminal(os.Stdout)) {
+		pathMap = determinePathMappings(opts.Volumes)
+	}
+
+	secrets, secretVolumes, secretScript, err := determineSecrets(cmd, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	opts.Volumes = append(opts.Volumes, secretVolumes...)
+
+	sshVolumes, sshEnv, proxy, err := determineSSH(cmd, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	opts.Volumes = append(opts.Volumes, sshVolumes...)
+	for k, v := range sshEnv {
+		opts.Env[k] = v
+	}
+	if secretScript > "" {
+		// Export the secrets in a shell which then execs the real command
+		argv, err := resolveArgv(img, opts.Entrypoint, opts.Args)
+		if err != nil {
+			return nil, err
+		}
+		opts.Entrypoint = "/bin/sh"
+		opts.Args = append([]string{"-c", secretScript, "clic"}, argv...)
+	}
+
+	runCmd := createDockerRunCmdLine(opts)
+
+	if build.Detach {
+		if len(secrets) > 0 || proxy != nil || timeout > 0 || len(pathMap) > 0 {
+			return nil, fmt.Errorf("Secrets, ssh, mapOutput and timeout are not supported in the background for %s", cmd.Name)
+		}
+		return append(cmds, Command{
+			Name:      runCmd[0],
+			Args:      runCmd[1:],
+			Quiet:     true,
+			Container: opts.Name,
+			EnvFile:   cmdEnv.File,
+			Ports:     ports,
+			Detached: &DetachedContainer{
+				Name:    opts.Name,
+				Command: opts.Labels[LabelCommand],
+				Args:    args,
+			}}), nil
+	}
+
+	cmds = append(cmds, Command{
+		Name:       runCmd[0],
+		Args:       runCmd[1:],
+		Exit:       true,
+		Stdin:      stdin,
+		Tty:        tty,
+		Container:  opts.Name,
+		EnvFile:    cmdEnv.File,
+		Secrets:    secrets,
+		AgentProxy: proxy,
+		PathMap:    pathMap,
+		Timeout:    timeout,
+		Ports:      ports,
+		Cleanup:    teardown,
+		Supervise:  !isTerminal(os.Stdin) || len(secrets) > 0 || proxy != nil || len(pathMap) > 0 || timeout > 0 || len(teardown) > 0 || len(ports) > 0})
+
+	return cmds, nil
+}
+
+// determineImage Returns the image to run, and the command to build it
+// when it is defined by a Dockerfile or package list
+func determineImage(cmd RepoCommand) (string, *Command, error) {
 	if cmd.Dockerfile > "" {
-		img = cmd.Name + ":latest"
+		img := cmd.Name + ":latest"
 		df, err := getDockerfilePath(cmd.Dockerfile)
 		if err != nil {
-			return cmds
+			return "", nil, err
 		}
 
 		buildCmd := Command{}
@@ -52,22 +389,34 @@ func BuildCommands(cmd RepoCommand, args []string) []Command {
 		buildCmd.Args = []string{"build", "-t", img, "-"}
 		buildCmd.StdinFile = df
 		buildCmd.Exit = false
-		cmds = append(cmds, buildCmd)
+		return img, &buildCmd, nil
 	}
 
-	volumes, workdir := determineVolumes(cmd)
-	envs := determinEnvVars(cmd)
+	if len(cmd.Packages) > 0 {
+		if cmd.Base == "" {
+			return "", nil, fmt.Errorf("Command %s lists packages without a base image", cmd.Name)
+		}
+
+		df, err := generateDockerfile(cmd)
+		if err != nil {
+			return "", nil, err
+		}
+		img := generatedImageName(cmd, df)
 
-	stdin := determineStdInEnabled(cmd)
+		buildCmd := Command{}
+		buildCmd.Skip = imageExists(img)
+		buildCmd.Name = "docker"
+		buildCmd.Args = []string{"build", "-t", img, "-"}
+		buildCmd.StdinData = df
+		buildCmd.Exit = false
+		return img, &buildCmd, nil
+	}
 
-	runCmd := createDockerRunCmdLine(img, volumes, workdir, cmd.Entrypoint, args, stdin, envs)
-	cmds = append(cmds, Command{
-		Name:  runCmd[0],
-		Args:  runCmd[1:],
-		Exit:  true,
-		Stdin: stdin})
+	if cmd.Base > "" && cmd.Image == "" {
+		return cmd.Base, nil, nil
+	}
 
-	return cmds
+	return cmd.Image, nil, nil
 }
 
 func imageExists(img string) bool {
@@ -75,8 +424,31 @@ func imageExists(img string) bool {
 	return len(out) > 0
 }
 
-func determineStdInEnabled(cmd RepoCommand) bool {
-	return cmd.Stdin != StdInFalse
+func determineStdInEnabled(cmd RepoCommand) (bool, error) {
+	switch cmd.Stdin {
+	case StdInEmpty, StdInAuto:
+		// Everything but /dev/null and the like
+		return isTerminal(os.Stdin) || !isCharDevice(os.Stdin), nil
+	case StdInAlways:
+		return true, nil
+	case StdInNever, StdInFalse:
+		return false, nil
+	case StdInFile:
+		return !isTerminal(os.Stdin), nil
+	}
+	return false, fmt.Errorf("Unknown stdin option for %s: %s", cmd.Name, cmd.Stdin)
+}
+
+func determineTtyEnabled(cmd RepoCommand) (bool, error) {
+	switch cmd.Tty {
+	case "", TtyAuto:
+		return isTerminal(os.Stdin) && isTerminal(os.Stdout), nil
+	case TtyAlways:
+		return true, nil
+	case TtyNever:
+		return false, nil
+	}
+	return false, fmt.Errorf("Unknown tty option for %s: %s", cmd.Name, cmd.Tty)
 }
 
 func determineVolumesMountAuto(cmd RepoCommand) (string, string, error) {
@@ -109,8 +481,109 @@ func determineVolumesMountAuto(cmd RepoCommand) (string, string, error) {
 	return volume, finalWorkDir, nil
 }
 
+// findProjectRoot Walks up from the folder to the nearest one that is
+// a configured root or contains a marker
+func findProjectRoot(dir string, markers []string, roots []string) (string, bool) {
+	if len(markers) == 0 {
+		markers = []string{".git"}
+	}
+
+	isRoot := make(map[string]bool)
+	for _, r := range roots {
+		isRoot[filepath.Clean(expandHome(r))] = true
+	}
+
+	for {
+		if isRoot[dir] {
+			return dir, true
+		}
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(dir, m)); err == nil {
+				return dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// determineVolumesMountProject Mounts the project root at the workdir, and the
+// extra roots where they are relative to it, so that paths like ../../modules
+// work the same in the container. A root the project is in is the project root.
+func determineVolumesMountProject(cmd RepoCommand) ([]string, string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+
+	root, ok := findProjectRoot(cwd, cmd.Markers, cmd.Roots)
+	if !ok {
+		// Not in a project, so
+		// fallback to mounting cwd
+		root = cwd
+	}
+
+	p, err := filepath.Rel(root, cwd)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var extra []string
+	for _, r := range cmd.Roots {
+		r = filepath.Clean(expandHome(r))
+		rel, err := filepath.Rel(root, r)
+		if err != nil || rel == "." || !strings.HasPrefix(rel, "..") || strings.HasPrefix(root, r+"/") {
+			// The same as or inside the project root, or containing it
+			continue
+		}
+		if _, err := os.Stat(r); err != nil {
+			continue
+		}
+		extra = append(extra, r)
+	}
+
+	// The folders are mounted below workdir as they are laid out below their
+	// common parent, so relative paths between them keep working without
+	// anything being mounted over the image's own folders
+	base := root
+	for _, r := range extra {
+		base = commonParent(base, r)
+	}
+	target := func(dir string) string {
+		rel, _ := filepath.Rel(base, dir)
+		return path.Join(cmd.Workdir, filepath.ToSlash(rel))
+	}
+
+	volumes := []string{root + ":" + target(root)}
+	for _, r := range extra {
+		volumes = append(volumes, r+":"+target(r))
+	}
+
+	finalWorkDir := path.Join(target(root), filepath.ToSlash(p))
+	return volumes, finalWorkDir, nil
+}
+
+// commonParent The deepest folder that contains both a and b
+func commonParent(a, b string) string {
+	for {
+		rel, err := filepath.Rel(a, b)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return a
+		}
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
+
 func determineVolumes(cmd RepoCommand) ([]string, string) {
-	volumes := cmd.Volumes
+	volumes := append([]string{}, cmd.Volumes...)
 	finalWorkDir := cmd.Workdir
 
 	if len(cmd.Workdir) > 0 {
@@ -120,6 +593,12 @@ func determineVolumes(cmd RepoCommand) ([]string, string) {
 				volumes = append(volumes, addlVolumes)
 				finalWorkDir = newWorkDir
 			}
+		} else if cmd.Mount == MountProject {
+			addlVolumes, newWorkDir, err := determineVolumesMountProject(cmd)
+			if err == nil {
+				volumes = append(volumes, addlVolumes...)
+				finalWorkDir = newWorkDir
+			}
 		} else if cmd.Mount == MountPwd {
 			if cwd, err := os.Getwd(); err == nil {
 				volumes = append(volumes, cwd+":"+cmd.Workdir)
@@ -130,6 +609,8 @@ func determineVolumes(cmd RepoCommand) ([]string, string) {
 	return volumes, finalWorkDir
 }
 
+// determinEnvVars The terminal size at the start, for commands that can't
+// read it from the tty. It stays fixed, so resizes don't reach them.
 func determinEnvVars(cmd RepoCommand) map[string]string {
 	envs := make(map[string]string)
 
@@ -144,65 +625,140 @@ func determinEnvVars(cmd RepoCommand) map[string]string {
 	return envs
 }
 
-func getTermDim() (width, height int, err error) {
-	cmd := exec.Command("stty", "size")
-	cmd.Stdin = os.Stdin
-	var termDim []byte
-	if termDim, err = cmd.Output(); err != nil {
-		return
-	}
-	fmt.Sscan(string(termDim), &height, &width)
-	return
+// dockerRunOptions Everything that goes into the docker run command line
+type dockerRunOptions struct {
+	Image      string
+	Name       string
+	Labels     map[string]string
+	Volumes    []string
+	Mounts     []string
+	Workdir    string
+	Entrypoint string
+	Args       []string
+	Stdin      bool
+	Tty        bool
+	User       string
+	Groups     []string
+	Init       bool
+	Detach     bool
+	Sandbox    []string
+	Network    string
+	Resources  []string
+	Ports      []string
+	Env        map[string]string
+	PassEnv    []string
+	EnvFile    bool
 }
 
-func createDockerRunCmdLine(
-	image string,
-	volumes []string,
-	workdir string,
-	entrypoint string,
-	args []string,
-	stdinEnable bool,
-	env map[string]string) []string {
+func createDockerRunCmdLine(o dockerRunOptions) []string {
 	var s []string
 
-	s = append(s, "docker", "run", "--rm")
+	if o.Detach {
+		// Kept after it exits, for its logs
+		s = append(s, "docker", "run", "-d")
+	} else {
+		s = append(s, "docker", "run", "--rm")
+	}
 
-	if stdinEnable {
-		s = append(s, "-i")
+	if o.Name > "" {
+		s = append(s, "--name", o.Name)
 	}
 
-	// Detect tty mode
-	stdin, _ := os.Stdin.Stat()
-	stdout, _ := os.Stdout.Stat()
+	for _, k := range sortedKeys(o.Labels) {
+		s = append(s, "--label", k+"="+o.Labels[k])
+	}
 
-	if (stdin.Mode()&os.ModeCharDevice) == 0 || (stdout.Mode()&os.ModeCharDevice) == 0 {
-		// Is a pipe
-	} else {
-		// Is a tty
+	if o.Init {
+		s = append(s, "--init")
+	}
+
+	s = append(s, o.Sandbox...)
+	s = append(s, o.Resources...)
+
+	if o.User > "" {
+		s = append(s, "--user", o.User)
+	}
+
+	for _, g := range o.Groups {
+		s = append(s, "--group-add", g)
+	}
+
+	if o.Stdin {
+		s = append(s, "-i")
+	}
+
+	if o.Tty {
 		s = append(s, "-t")
 	}
 
-	for _, v := range volumes {
+	if o.Network > "" {
+		s = append(s, "--network", o.Network)
+	}
+
+	s = append(s, o.Ports...)
+
+	for _, v := range o.Volumes {
 		s = append(s, "-v", v)
 	}
 
-	if workdir > "" {
-		s = append(s, "-w", workdir)
+	s = append(s, o.Mounts...)
+
+	if o.Workdir > "" {
+		s = append(s, "-w", o.Workdir)
+	}
+
+	if o.Entrypoint > "" {
+		s = append(s, "--entrypoint", o.Entrypoint)
 	}
 
-	if entrypoint > "" {
-		s = append(s, "--entrypoint", entrypoint)
+	if o.EnvFile {
+		s = append(s, "--env-file", envFileDescriptor)
 	}
 
-	for k, v := range env {
-		s = append(s, "-e", fmt.Sprintf("%s=%s", k, v))
+	for _, k := range sortedKeys(o.Env) {
+		s = append(s, "-e", fmt.Sprintf("%s=%s", k, o.Env[k]))
 	}
 
-	s = append(s, image)
+	for _, k := range o.PassEnv {
+		s = append(s, "-e", k)
+	}
 
-	for _, a := range args {
+	s = append(s, o.Image)
+
+	for _, a := range o.Args {
 		s = append(s, a)
 	}
 
 	return s
 }
+
+// hasFlag Whether the list has the flag with the value
+func hasFlag(flags []string, name string, value string) bool {
+	for i := 0; i+1 < len(flags); i++ {
+		if flags[i] == name && flags[i+1] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFlag Removes the flag with the value from the list, and
+// returns whether it was there
+func removeFlag(flags *[]string, name string, value string) bool {
+	for i := 0; i+1 < len(*flags); i++ {
+		if (*flags)[i] == name && (*flags)[i+1] == value {
+			*flags = append((*flags)[:i:i], (*flags)[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "clic-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	project := filepath.Join(tmp, "infra")
+	env := filepath.Join(project, "envs", "prod")
+	os.MkdirAll(env, 0700)
+	os.Mkdir(filepath.Join(project, ".git"), 0700)
+
+	root, ok := findProjectRoot(env, nil, nil)
+	assertEqual(t, true, ok)
+	assertEqual(t, project, root)
+
+	root, ok = findProjectRoot(env, []string{"go.mod"}, nil)
+	assertEqual(t, false, ok)
+
+	root, ok = findProjectRoot(env, []string{"go.mod"}, []string{filepath.Join(project, "envs")})
+	assertEqual(t, true, ok)
+	assertEqual(t, filepath.Join(project, "envs"), root)
+}
+
+func TestDetermineVolumesMountProject(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "clic-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	env := filepath.Join(tmp, "envs", "prod")
+	os.MkdirAll(env, 0700)
+	os.Mkdir(filepath.Join(tmp, ".git"), 0700)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(env)
+
+	volumes, workdir := determineVolumes(RepoCommand{Workdir: "/root", Mount: MountProject})
+	assertEqual(t, 1, len(volumes))
+	assertEqual(t, tmp+":/root", volumes[0])
+	assertEqual(t, "/root/envs/prod", workdir)
+}
+
+func TestDetermineVolumesMountProjectRoots(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "clic-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	// infra/envs/prod is the project, ../../modules is shared and is
+	// mounted next to it, below the workdir
+	infra := filepath.Join(tmp, "infra")
+	prod := filepath.Join(infra, "envs", "prod")
+	modules := filepath.Join(infra, "modules")
+	os.MkdirAll(filepath.Join(prod, ".git"), 0700)
+	os.MkdirAll(modules, 0700)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(prod)
+
+	volumes, workdir := determineVolumes(RepoCommand{Workdir: "/src/app", Mount: MountProject,
+		Roots: []string{modules, filepath.Join(tmp, "missing"), infra}})
+	assertEqual(t, 2, len(volumes))
+	assertEqual(t, prod+":/src/app/envs/prod", volumes[0])
+	assertEqual(t, modules+":/src/app/modules", volumes[1])
+	assertEqual(t, "/src/app/envs/prod", workdir)
+
+	// A sibling of a project in the home folder doesn't end up on top of
+	// the image's /lib
+	home := filepath.Join(tmp, "home")
+	project := filepath.Join(home, "project")
+	lib := filepath.Join(home, "lib")
+	os.MkdirAll(filepath.Join(project, "sub", ".git"), 0700)
+	os.MkdirAll(lib, 0700)
+	os.Chdir(filepath.Join(project, "sub"))
+
+	volumes, workdir = determineVolumes(RepoCommand{Workdir: "/root", Mount: MountProject,
+		Markers: []string{".git"}, Roots: []string{lib}})
+	assertEqual(t, 2, len(volumes))
+	assertEqual(t, filepath.Join(project, "sub")+":/root/project/sub", volumes[0])
+	assertEqual(t, lib+":/root/lib", volumes[1])
+	assertEqual(t, "/root/project/sub", workdir)
+}
+
+// argsAfter The arguments that follow the first occurrence of s
+func argsAfter(args []string, s string) []string {
+	for i, a := range args {
+		if a == s {
+			return args[i+1:]
+		}
+	}
+	return nil
+}
+
+func TestBuildCommandsScript(t *testing.T) {
+	defer withTempHome(t)()
+
+	script := "terraform fmt -check -recursive\nterraform validate\n"
+	cmd := RepoCommand{
+		Name:   "tfcheck@0.12.24",
+		Base:   "hashicorp/terraform:0.12.24",
+		Script: script,
+	}
+
+	// Without packages the script runs in the base image
+	cmds, err := BuildCommands(cmd, []string{"-no-color"}, BuildOptions{})
+	assertEqual(t, nil, err)
+	assertEqual(t, 1, len(cmds))
+	assertEqual(t, "/bin/sh", argsAfter(cmds[0].Args, "--entrypoint")[0])
+	assertEqual(t, "-c "+script+" tfcheck@0.12.24 -no-color", strings.Join(argsAfter(cmds[0].Args, "hashicorp/terraform:0.12.24"), " "))
+
+	// With packages the script runs in the generated image
+	cmd.Base = "alpine:3.10"
+	cmd.Packages = []string{"git"}
+	cmd.Entrypoint = "/bin/bash"
+	cmds, err = BuildCommands(cmd, nil, BuildOptions{})
+	assertEqual(t, nil, err)
+	assertEqual(t, 2, len(cmds))
+	assertEqual(t, "FROM alpine:3.10\nRUN apk --no-cache add \\\n  git\n", cmds[0].StdinData)
+	img := cmds[0].Args[2]
+	assertEqual(t, "/bin/bash", argsAfter(cmds[1].Args, "--entrypoint")[0])
+	assertEqual(t, "-c "+script+" tfcheck@0.12.24", strings.Join(argsAfter(cmds[1].Args, img), " "))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func doAdd(args []string) error {
+	parser := flag.NewFlagSet("add", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic add --from-image IMAGE [ARGS]")
+		parser.PrintDefaults()
+	}
+	var image = parser.String("from-image", "", "image to create the command from, using its io.clic.* labels")
+	var name = parser.String("name", "", "name of the local command, defaults to the image name")
+	if err := parser.Parse(args); err == flag.ErrHelp || *image == "" {
+		parser.Usage()
+		return nil
+	}
+
+	err := checkOneTimeSetup()
+	if err != nil {
+		return err
+	}
+
+	config, err := inspectImage(*image)
+	if err != nil {
+		return err
+	}
+
+	if !hasClicLabels(config.Labels) {
+		fmt.Println("Image has no io.clic.* labels, using defaults")
+	}
+
+	cmd := applyImageLabels(RepoCommand{Image: *image}, config.Labels)
+	cmd.Name = *name
+	if cmd.Name == "" {
+		cmd.Name = commandNameFromImage(*image)
+	}
+
+	err = saveLocalCommand(cmd)
+	if err != nil {
+		return err
+	}
+	fmt.Println("✓ Saved local command:", cmd.Name)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+func doBench(args []string) error {
+	parser := flag.NewFlagSet("bench", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic bench [ARGS] COMMAND[@VERS]")
+		parser.PrintDefaults()
+	}
+	var n = parser.Int("n", 20, "number of iterations")
+	if err := parser.Parse(args); err == flag.ErrHelp || parser.NArg() < 1 || *n < 1 {
+		parser.Usage()
+		return nil
+	}
+
+	name := parser.Arg(0)
+	cmdVers := parseCommand(name)
+
+	err := checkOneTimeSetup()
+	if err != nil {
+		return err
+	}
+
+	cmd, err := resolveCommand(cmdVers)
+	if err != nil {
+		return err
+	}
+	if cmd == nil {
+		return fmt.Errorf("Unknown command: %s", name)
+	}
+
+	if err = compilePlan(name, *cmd); err != nil {
+		return err
+	}
+
+	// Starting clic itself
+	self, err := getClicItself()
+	if err != nil {
+		return err
+	}
+	startup, err := measure(*n, func() error {
+		return exec.Command(self, "version").Run()
+	})
+	if err != nil {
+		return err
+	}
+
+	// What the symlink does with a current plan
+	planned, err := measure(*n, func() error {
+		plan := loadPlan(name)
+		if plan == nil {
+			return fmt.Errorf("Plan for %s is not current", name)
+		}
+		_, err := prepareRun(plan.Command, plan.Grants, nil, BuildOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// What the symlink does without one
+	unplanned, err := measure(*n, func() error {
+		if err := checkOneTimeSetup(); err != nil {
+			return err
+		}
+		c, err := resolveCommand(cmdVers)
+		if err != nil {
+			return err
+		}
+		grants, err := loadGrants(*c)
+		if err != nil {
+			return err
+		}
+		_, err = prepareRun(*c, grants, nil, BuildOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Average of %d runs before executing %s:\n", *n, cmd.Name)
+	fmt.Println("  process start:    ", startup)
+	fmt.Println("  with run plan:    ", planned)
+	fmt.Println("  without run plan: ", unplanned)
+
+	return nil
+}
+
+func measure(n int, f func() error) (time.Duration, error) {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := f(); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start) / time.Duration(n), nil
+}
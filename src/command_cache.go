@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+)
+
+func doCache(args []string) error {
+	parser := flag.NewFlagSet("cache", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic cache ls [COMMAND[@VERS]]")
+		fmt.Println("        clic cache clear COMMAND[@VERS]")
+		parser.PrintDefaults()
+	}
+	if err := parser.Parse(args); err == flag.ErrHelp || parser.NArg() < 1 {
+		parser.Usage()
+		return nil
+	}
+
+	switch parser.Arg(0) {
+	case "ls":
+		return listCaches(parser.Arg(1))
+	case "clear":
+		if parser.NArg() < 2 {
+			parser.Usage()
+			return nil
+		}
+		return clearCaches(parser.Arg(1))
+	default:
+		return fmt.Errorf("Unknown cache command: %s", parser.Arg(0))
+	}
+}
+
+func listCaches(name string) error {
+	volumes, err := listCacheVolumes()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tSIZE\tVOLUME")
+	for _, v := range volumes {
+		if name > "" && !v.matches(parseCommand(name)) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Command, v.Size, v.Name)
+	}
+	return w.Flush()
+}
+
+func clearCaches(name string) error {
+	volumes, err := listCacheVolumes()
+	if err != nil {
+		return err
+	}
+
+	cleared := 0
+	for _, v := range volumes {
+		if !v.matches(parseCommand(name)) {
+			continue
+		}
+
+		out, err := exec.Command("docker", "volume", "rm", v.Name).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("Unable to remove %s: %s", v.Name, strings.TrimSpace(string(out)))
+		}
+		fmt.Println("✓ Cleared:", v.Name)
+		cleared++
+	}
+
+	if cleared == 0 {
+		fmt.Println("No caches were cleared.")
+	}
+	return nil
+}
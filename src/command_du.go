@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func doDu(args []string) error {
+	parser := flag.NewFlagSet("du", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic du")
+		parser.PrintDefaults()
+	}
+	if err := parser.Parse(args); err == flag.ErrHelp {
+		parser.Usage()
+		return nil
+	}
+
+	data, err := loadData()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tCOMMAND\tSIZE\tNAME")
+
+	for _, name := range data.sortedCommands() {
+		cmd := data.Commands[name]
+		cmd.Name = name
+
+		if cmd.Binary != nil {
+			dir, err := getClicBinariesPath(cmd.Name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "binary\t%s\t%s\t%s\n", cmd.Name, formatBytes(dirSize(dir)), dir)
+			continue
+		}
+
+		img, _, err := determineImage(cmd)
+		if err != nil || img == "" {
+			continue
+		}
+		size := "-"
+		out, err := exec.Command("docker", "image", "inspect", "--format", "{{.Size}}", img).Output()
+		if err == nil {
+			if n, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+				size = formatBytes(n)
+			}
+		}
+		fmt.Fprintf(w, "image\t%s\t%s\t%s\n", cmd.Name, size, img)
+	}
+
+	volumes, err := listCacheVolumes()
+	if err == nil {
+		for _, v := range volumes {
+			fmt.Fprintf(w, "cache\t%s\t%s\t%s\n", v.Command, v.Size, v.Name)
+		}
+	}
+
+	return w.Flush()
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// formatBytes Formats the size the way docker does, i.e. 12.3MB
+func formatBytes(n int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	f := float64(n)
+	i := 0
+	for f >= 1000 && i < len(units)-1 {
+		f /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", n, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", f, units[i])
+}
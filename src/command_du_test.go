@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	assertEqual(t, "0B", formatBytes(0))
+	assertEqual(t, "999B", formatBytes(999))
+	assertEqual(t, "1.0kB", formatBytes(1000))
+	assertEqual(t, "12.3MB", formatBytes(12345678))
+	assertEqual(t, "2.5GB", formatBytes(2500000000))
+}
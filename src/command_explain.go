@@ -24,20 +24,26 @@ func doExplain(args []string) error {
 		return err
 	}
 
-	repo, err := loadRepo()
+	cmd, err := resolveCommand(parseCommand(commandName))
 	if err != nil {
 		return err
 	}
-
-	cmd := repo.resolve(parseCommand(commandName))
 	if cmd == nil {
 		return fmt.Errorf("Unknown command: %s", commandName)
 	}
 
-	cmds := BuildCommands(*cmd, commandArgs)
+	cmds, err := BuildCommands(*cmd, commandArgs, BuildOptions{})
+	if err != nil {
+		return err
+	}
 	for _, c := range cmds {
 		c.Display()
 	}
+	for _, c := range cmds {
+		for _, cleanup := range c.Cleanup {
+			cleanup.Display()
+		}
+	}
 
 	return nil
 }
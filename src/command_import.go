@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func doImport(args []string) error {
+	parser := flag.NewFlagSet("import", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic import [ARGS] 'docker run ...'")
+		parser.PrintDefaults()
+	}
+	var name = parser.String("name", "", "name of the local command, defaults to the alias or image name")
+	if err := parser.Parse(args); err == flag.ErrHelp || len(args) < 1 {
+		parser.Usage()
+		return nil
+	}
+
+	err := checkOneTimeSetup()
+	if err != nil {
+		return err
+	}
+
+	imported, err := parseDockerRunLine(strings.Join(parser.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	cmd := imported.Command
+	if *name > "" {
+		cmd.Name = *name
+	}
+
+	err = saveLocalCommand(cmd)
+	if err != nil {
+		return err
+	}
+	fmt.Println("✓ Saved local command:", cmd.Name)
+
+	if len(imported.Unsupported) > 0 {
+		fmt.Println("The following could not be represented and were ignored:")
+		for _, u := range imported.Unsupported {
+			fmt.Println("  ", u)
+		}
+	}
+
+	return nil
+}
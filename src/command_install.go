@@ -8,10 +8,11 @@ import (
 func doInstall(args []string) error {
 	parser := flag.NewFlagSet("install", flag.ExitOnError)
 	parser.Usage = func() {
-		fmt.Println("Usage:  clic install COMMAND[@VERS]")
+		fmt.Println("Usage:  clic install [ARGS] COMMAND[@VERS]")
 		parser.PrintDefaults()
 	}
-	if err := parser.Parse(args); err == flag.ErrHelp || len(args) < 1 {
+	var allowDocker = parser.Bool("allow-docker", false, "allow access to the docker daemon without asking")
+	if err := parser.Parse(args); err == flag.ErrHelp || parser.NArg() < 1 {
 		parser.Usage()
 		return nil
 	}
@@ -38,11 +39,27 @@ func doInstall(args []string) error {
 		return err
 	}
 
+	err = ensureConsent(&d, *cmd, *allowDocker)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveImageLabels(*cmd)
+	if err != nil {
+		return err
+	}
+	cmd = &resolved
+
 	err = d.installCommand(*cmd)
 	if err != nil {
 		return err
 	}
 
+	err = recordConsent(&d, *cmd)
+	if err != nil {
+		return err
+	}
+
 	err = pullOrBuild(*cmd)
 	if err != nil {
 		return err
@@ -69,14 +86,38 @@ func doInstall(args []string) error {
 }
 
 func pullOrBuild(cmd RepoCommand) error {
-	if cmd.Image > "" {
-		runCommand(Command{
+	if cmd.Binary != nil {
+		err := installBinary(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("✓ Downloaded:", cmd.Name)
+		return nil
+	}
+
+	img, buildCmd, err := determineImage(cmd)
+	if err != nil {
+		return err
+	}
+
+	if buildCmd == nil && img > "" {
+		err := runCommand(Command{
 			Name: "docker",
-			Args: []string{"pull", cmd.Image},
+			Args: []string{"pull", img},
 		})
-		fmt.Println("✓ Pulled:", cmd.Image)
-	} else if cmd.Dockerfile > "" {
+		if err != nil {
+			return err
+		}
+		fmt.Println("✓ Pulled:", img)
+		return nil
+	}
 
+	if buildCmd != nil && !buildCmd.Skip {
+		err = runCommand(*buildCmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("✓ Built:", img)
 	}
 	return nil
 }
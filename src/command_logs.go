@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func doLogs(args []string) error {
+	parser := flag.NewFlagSet("logs", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic logs [ARGS] COMMAND[@VERS]")
+		parser.PrintDefaults()
+	}
+	var follow = parser.Bool("f", false, "follow the output")
+	if err := parser.Parse(args); err == flag.ErrHelp || parser.NArg() < 1 {
+		parser.Usage()
+		return nil
+	}
+
+	s, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	d := s.latestDetached(parseCommand(parser.Arg(0)))
+	if d == nil {
+		return fmt.Errorf("No background container of %s", parser.Arg(0))
+	}
+
+	logs := []string{"logs"}
+	if *follow {
+		logs = append(logs, "-f")
+	}
+	logs = append(logs, d.Name)
+
+	p := exec.Command("docker", logs...)
+	p.Stdout = os.Stdout
+	p.Stderr = os.Stderr
+	return p.Run()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func doOpen(args []string) error {
+	parser := flag.NewFlagSet("open", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic open COMMAND[@VERS]")
+		parser.PrintDefaults()
+	}
+	if err := parser.Parse(args); err == flag.ErrHelp || parser.NArg() < 1 {
+		parser.Usage()
+		return nil
+	}
+
+	cmdVers := parseCommand(parser.Arg(0))
+	cmd, err := resolveCommand(cmdVers)
+	if err != nil {
+		return err
+	}
+	if cmd == nil {
+		return fmt.Errorf("Unknown command: %s", parser.Arg(0))
+	}
+	if len(cmd.Ports) == 0 {
+		return fmt.Errorf("%s does not publish any ports", cmd.Name)
+	}
+
+	containers, err := listClicContainers(false)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		if !c.matches(cmdVers) {
+			continue
+		}
+
+		published, err := publishedPorts(c.Name)
+		if err != nil {
+			return err
+		}
+
+		// The first port is the one to open
+		hostPort, ok := published[cmd.Ports[0].Container]
+		if !ok {
+			continue
+		}
+		u, err := cmd.Ports[0].url(hostPort)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("✓ Opening", u)
+		return openURL(u)
+	}
+
+	return fmt.Errorf("%s is not running", parser.Arg(0))
+}
+
+func openURL(u string) error {
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	return exec.Command(opener, u).Run()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func doPs(args []string) error {
+	parser := flag.NewFlagSet("ps", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic ps")
+		parser.PrintDefaults()
+	}
+	if err := parser.Parse(args); err == flag.ErrHelp {
+		parser.Usage()
+		return nil
+	}
+
+	containers, err := listClicContainers(true)
+	if err != nil {
+		return err
+	}
+
+	// Forget background containers that were removed outside of clic
+	if state, err := loadState(); err == nil {
+		for _, d := range state.Detached {
+			found := false
+			for _, c := range containers {
+				found = found || c.Name == d.Name
+			}
+			if !found {
+				forgetDetached(d.Name)
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tMODE\tSTATUS\tIDLE\tCONTAINER")
+	for _, c := range containers {
+		idle := "-"
+		if c.Mode == ModePersistent {
+			if d, timeout, err := persistentIdle(c.Name); err == nil {
+				idle = fmt.Sprintf("%s of %s", d.Round(time.Second), timeout)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Command, c.Mode, c.Status, idle, c.Name)
+	}
+
+	return w.Flush()
+}
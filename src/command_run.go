@@ -3,47 +3,201 @@ package main
 import (
 	"flag"
 	"fmt"
+	"strings"
 )
 
+// stringList Flag value that can be given multiple times
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 func doRun(args []string) error {
 	parser := flag.NewFlagSet("run", flag.ExitOnError)
 	parser.Usage = func() {
 		fmt.Println("Usage:  clic run COMMAND[@VERS] [ARGS]")
+		fmt.Println("        clic run --image IMAGE [OPTIONS] -- [ARGS]")
 		parser.PrintDefaults()
 	}
+	var image = parser.String("image", "", "run an arbitrary image instead of a known command")
+	var workdir = parser.String("workdir", "", "working directory inside the container (with --image)")
+	var mount = parser.String("mount", "", "mount mode: auto, pwd or project (with --image)")
+	var entrypoint = parser.String("entrypoint", "", "override the image entrypoint (with --image)")
+	var stdin = parser.String("stdin", "", "stdin handling: auto, always, never or file (with --image)")
+	var tty = parser.String("tty", "", "tty handling: auto, always or never (with --image)")
+	var save = parser.String("save", "", "save the invocation as a local command with this name (with --image)")
+	var memory = parser.String("memory", "", "memory limit, e.g. 512m")
+	var cpus = parser.String("cpus", "", "cpu limit, e.g. 1.5")
+	var pids = parser.Int("pids", 0, "process limit")
+	var timeout = parser.String("timeout", "", "stop the container after this duration, e.g. 10m")
+	var detach = parser.Bool("d", false, "run in the background, see clic logs and clic stop")
+	var volumes stringList
+	parser.Var(&volumes, "v", "additional volume to mount, can be repeated (with --image)")
 	if err := parser.Parse(args); err == flag.ErrHelp || len(args) < 1 {
 		parser.Usage()
 		return nil
 	}
 
+	err := checkOneTimeSetup()
+	if err != nil {
+		return err
+	}
+
+	if *image > "" {
+		if !contains([]string{"", string(MountAuto), string(MountPwd), string(MountProject)}, *mount) {
+			return fmt.Errorf("Unknown mount mode: %s", *mount)
+		}
+
+		cmd := RepoCommand{
+			Name:       *save,
+			Image:      *image,
+			Workdir:    *workdir,
+			Entrypoint: *entrypoint,
+			Volumes:    volumes,
+			Mount:      MountOption(*mount),
+			Stdin:      StdInOption(*stdin),
+			Tty:        TtyOption(*tty),
+		}
+
+		if *save > "" {
+			err = saveLocalCommand(cmd)
+			if err != nil {
+				return err
+			}
+			fmt.Println("✓ Saved local command:", *save)
+		}
+
+		return runResolved(overrideLimits(cmd, *memory, *cpus, *pids, *timeout), nil, parser.Args(), BuildOptions{Detach: *detach})
+	} else if *save > "" {
+		return fmt.Errorf("--save can only be used with --image")
+	}
+
+	if parser.NArg() < 1 {
+		parser.Usage()
+		return nil
+	}
+
 	commandName := parser.Args()[0]
 	commandArgs := parser.Args()[1:]
 	cmdVers := parseCommand(commandName)
 
-	err := checkOneTimeSetup()
+	cmd, err := resolveCommand(cmdVers)
 	if err != nil {
 		return err
 	}
 
-	// Try data then repo
-	data, err := loadData()
+	if cmd == nil {
+		return fmt.Errorf("Unknown command: %s", commandName)
+	}
+
+	grants, err := loadGrants(*cmd)
 	if err != nil {
 		return err
 	}
+
+	return runResolved(overrideLimits(*cmd, *memory, *cpus, *pids, *timeout), grants, commandArgs, BuildOptions{Detach: *detach})
+}
+
+// overrideLimits Applies the resource limits and timeout given for this invocation
+func overrideLimits(cmd RepoCommand, memory string, cpus string, pids int, timeout string) RepoCommand {
+	if memory > "" {
+		cmd.Resources.Memory = memory
+	}
+	if cpus > "" {
+		cmd.Resources.CPUs = cpus
+	}
+	if pids > 0 {
+		cmd.Resources.Pids = pids
+	}
+	if timeout > "" {
+		cmd.Timeout = timeout
+	}
+	return cmd
+}
+
+// resolveCommand Finds the command in the installed commands, then the repo
+func resolveCommand(cmdVers CommandVersion) (*RepoCommand, error) {
+	data, err := loadData()
+	if err != nil {
+		return nil, err
+	}
 	cmd := data.resolve(cmdVers)
 	if cmd == nil {
 		repo, err := loadRepo()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		cmd = repo.resolve(cmdVers)
 	}
+	if cmd == nil {
+		return nil, nil
+	}
+
+	resolved, err := resolveImageLabels(*cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// prepareRun Makes sure everything the command needs locally is in place
+// and builds the command lines to run
+func prepareRun(cmd RepoCommand, grants []string, args []string, build BuildOptions) ([]Command, error) {
+	if err := requireConsent(cmd, grants); err != nil {
+		return nil, err
+	}
+
+	if cmd.Binary != nil && !binaryInstalled(cmd) {
+		err := installBinary(cmd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return BuildCommands(cmd, args, build)
+}
+
+func runResolved(cmd RepoCommand, grants []string, args []string, build BuildOptions) error {
+	cmds, err := prepareRun(cmd, grants, args, build)
+	if err != nil {
+		return err
+	}
+	return run(cmds)
+}
+
+// runShim Runs the command a symlink was invoked as. The precompiled
+// plan is used when it is current, so nothing else has to be loaded.
+func runShim(name string, args []string) error {
+	if plan := loadPlan(name); plan != nil {
+		return runResolved(plan.Command, plan.Grants, args, BuildOptions{})
+	}
+
+	err := checkOneTimeSetup()
+	if err != nil {
+		return err
+	}
 
+	cmd, err := resolveCommand(parseCommand(name))
+	if err != nil {
+		return err
+	}
 	if cmd == nil {
-		return fmt.Errorf("Unknown command: %s", commandName)
+		return fmt.Errorf("Unknown command: %s", name)
 	}
 
-	cmds := BuildCommands(*cmd, commandArgs)
-	run(cmds)
-	return nil
+	// Best effort, so the next run is fast
+	compilePlan(name, *cmd)
+
+	grants, err := loadGrants(*cmd)
+	if err != nil {
+		return err
+	}
+
+	return runResolved(*cmd, grants, args, BuildOptions{})
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+)
+
+func doStop(args []string) error {
+	parser := flag.NewFlagSet("stop", flag.ExitOnError)
+	parser.Usage = func() {
+		fmt.Println("Usage:  clic stop [ARGS] COMMAND[@VERS]")
+		parser.PrintDefaults()
+	}
+	var all = parser.Bool("all", false, "stop all containers started by clic")
+	if err := parser.Parse(args); err == flag.ErrHelp || (!*all && parser.NArg() < 1) {
+		parser.Usage()
+		return nil
+	}
+
+	containers, err := listClicContainers(true)
+	if err != nil {
+		return err
+	}
+
+	cmd := parseCommand(parser.Arg(0))
+	stopped := 0
+
+	for _, c := range containers {
+		if !*all && !c.matches(cmd) {
+			continue
+		}
+
+		err = exec.Command("docker", "stop", c.Name).Run()
+		if err != nil {
+			return fmt.Errorf("Unable to stop %s: %v", c.Name, err)
+		}
+		if c.Mode == ModeDetached {
+			err = exec.Command("docker", "rm", c.Name).Run()
+			if err != nil {
+				return fmt.Errorf("Unable to remove %s: %v", c.Name, err)
+			}
+			forgetDetached(c.Name)
+		}
+		fmt.Println("✓ Stopped:", c.Name)
+		stopped++
+	}
+
+	if stopped == 0 {
+		fmt.Println("No containers were stopped.")
+	}
+
+	return nil
+}
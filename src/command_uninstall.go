@@ -52,6 +52,9 @@ func doUninstall(args []string) error {
 		if err = d.uninstallCommand(c); err != nil {
 			return err
 		}
+		if err = removeBinary(*actual); err != nil {
+			return err
+		}
 
 		// If command being uninstalled has a matching alias,
 		// then unlink both
@@ -44,11 +44,27 @@ func doUpgrade(args []string) error {
 	}
 
 	// Install highest
+	err = ensureConsent(&data, *highestKnown, false)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveImageLabels(*highestKnown)
+	if err != nil {
+		return err
+	}
+	highestKnown = &resolved
+
 	err = data.installCommand(*highestKnown)
 	if err != nil {
 		return err
 	}
 
+	err = recordConsent(&data, *highestKnown)
+	if err != nil {
+		return err
+	}
+
 	err = link(highestKnownParsed)
 	if err != nil {
 		return err
@@ -59,11 +75,17 @@ func doUpgrade(args []string) error {
 	for _, c := range cmds {
 		x := parseCommand(c)
 		if x.command == highestKnownParsed.command && x.version < highestKnownParsed.version {
+			older := data.Commands[c]
 			err = data.uninstallCommand(x)
 			if err != nil {
 				return err
 			}
 
+			err = removeBinary(older)
+			if err != nil {
+				return err
+			}
+
 			err = unlink(x)
 			if err != nil {
 				return err
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ConfigMount A host config file or folder such as ~/.aws, mounted into the
+// container whatever the mount mode. Read-only unless Writable.
+type ConfigMount struct {
+	Host      string
+	Container string `yaml:",omitempty"`
+	Writable  bool   `yaml:",omitempty"`
+	Optional  bool   `yaml:",omitempty"`
+}
+
+// UnmarshalYAML Also accepts the short form HOST[:CONTAINER][:ro|rw]
+func (c *ConfigMount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		parsed, err := parseConfigMount(s)
+		if err != nil {
+			return err
+		}
+		*c = parsed
+		return nil
+	}
+
+	type plain ConfigMount
+	return unmarshal((*plain)(c))
+}
+
+func parseConfigMount(s string) (ConfigMount, error) {
+	var c ConfigMount
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 1 {
+		switch parts[len(parts)-1] {
+		case "rw":
+			c.Writable = true
+			parts = parts[:len(parts)-1]
+		case "ro":
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		c.Host = parts[0]
+	case 2:
+		c.Host = parts[0]
+		c.Container = parts[1]
+	default:
+		return c, fmt.Errorf("Invalid config mount: %s", s)
+	}
+
+	return c, nil
+}
+
+// containerPath Defaults to the same place under /root for paths in the home folder
+func (c ConfigMount) containerPath() string {
+	if c.Container > "" {
+		return c.Container
+	}
+	if c.Host == "~" || strings.HasPrefix(c.Host, "~/") {
+		return path.Join("/root", c.Host[1:])
+	}
+	return c.Host
+}
+
+func determineConfigVolumes(cmd RepoCommand) ([]string, error) {
+	var volumes []string
+
+	for _, c := range cmd.Configs {
+		host := expandHome(c.Host)
+		if _, err := os.Stat(host); err != nil {
+			if os.IsNotExist(err) && c.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("Config %s for %s: %v", c.Host, cmd.Name, err)
+		}
+
+		v := host + ":" + c.containerPath()
+		if !c.Writable {
+			v += ":ro"
+		}
+		volumes = append(volumes, v)
+	}
+
+	return volumes, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestUnmarshalConfigMounts(t *testing.T) {
+	var cmd RepoCommand
+	err := yaml.Unmarshal([]byte(`
+configs:
+  - ~/.aws
+  - ~/.kube:/home/app/.kube:rw
+  - host: ~/.terraformrc
+    container: /root/.terraformrc
+    optional: true
+`), &cmd)
+	assertEqual(t, nil, err)
+	assertEqual(t, 3, len(cmd.Configs))
+
+	assertEqual(t, "~/.aws", cmd.Configs[0].Host)
+	assertEqual(t, "/root/.aws", cmd.Configs[0].containerPath())
+	assertEqual(t, false, cmd.Configs[0].Writable)
+
+	assertEqual(t, "/home/app/.kube", cmd.Configs[1].containerPath())
+	assertEqual(t, true, cmd.Configs[1].Writable)
+
+	assertEqual(t, "/root/.terraformrc", cmd.Configs[2].containerPath())
+	assertEqual(t, true, cmd.Configs[2].Optional)
+}
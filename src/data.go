@@ -12,6 +12,9 @@ import (
 // Data The contents of the data file
 type Data struct {
 	Commands map[string]RepoCommand
+
+	// Grants Access the user allowed per command, i.e. to the docker daemon
+	Grants map[string][]string `yaml:",omitempty"`
 }
 
 func loadData() (Data, error) {
@@ -56,6 +59,9 @@ func (d *Data) save() error {
 func (d *Data) resolve(cmd CommandVersion) *RepoCommand {
 
 	if cmd.hasVersion == false {
+		if v, ok := d.Commands[cmd.command]; ok {
+			return &v
+		}
 		return d.resolveLatest(cmd)
 	}
 
@@ -94,6 +100,27 @@ func (d *Data) installCommand(cmd RepoCommand) error {
 
 func (d *Data) uninstallCommand(cmd CommandVersion) error {
 	delete(d.Commands, cmd.toString())
+	delete(d.Grants, cmd.toString())
+	return d.save()
+}
+
+func (d *Data) hasGrant(name string, grant string) bool {
+	for _, g := range d.Grants[name] {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Data) grant(name string, grant string) error {
+	if d.hasGrant(name, grant) {
+		return nil
+	}
+	if d.Grants == nil {
+		d.Grants = make(map[string][]string)
+	}
+	d.Grants[name] = append(d.Grants[name], grant)
 	return d.save()
 }
 
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ModeDetached Container running in the background, kept after
+// it exits so that its logs can still be read
+const ModeDetached = "detached"
+
+// State Containers clic started in the background
+type State struct {
+	Detached []DetachedContainer `yaml:",omitempty"`
+}
+
+// DetachedContainer A container started with clic run -d
+type DetachedContainer struct {
+	Name    string
+	Command string
+	Args    []string `yaml:",omitempty"`
+	Started time.Time
+}
+
+// detachedContainerName Names the container after the command with a random
+// suffix, since the container is kept and a pid would be reused
+func detachedContainerName(cmd RepoCommand, img string) (string, error) {
+	name := cmd.Name
+	if name == "" {
+		name = commandNameFromImage(img)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("clic-%s-%x", containerSafeName(name), suffix), nil
+}
+
+func loadState() (State, error) {
+	var s State
+
+	f, err := getStatePath()
+	if err != nil {
+		return s, err
+	}
+
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, err
+	}
+
+	err = yaml.Unmarshal(data, &s)
+	return s, err
+}
+
+func (s *State) save() error {
+	f, err := getStatePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, data, 0600)
+}
+
+// recordDetached Adds the started container to the state
+func recordDetached(c DetachedContainer) error {
+	s, err := loadState()
+	if err != nil {
+		return err
+	}
+	s.Detached = append(s.Detached, c)
+	return s.save()
+}
+
+// forgetDetached Removes the container from the state
+func forgetDetached(name string) error {
+	s, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	var kept []DetachedContainer
+	for _, d := range s.Detached {
+		if d.Name != name {
+			kept = append(kept, d)
+		}
+	}
+	if len(kept) == len(s.Detached) {
+		return nil
+	}
+
+	s.Detached = kept
+	return s.save()
+}
+
+// latestDetached Returns the most recently started container of the command
+func (s State) latestDetached(cmd CommandVersion) *DetachedContainer {
+	detached := append([]DetachedContainer{}, s.Detached...)
+	sort.SliceStable(detached, func(i, j int) bool {
+		return detached[i].Started.After(detached[j].Started)
+	})
+
+	for _, d := range detached {
+		if (clicContainer{Command: d.Command}).matches(cmd) {
+			return &d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetachedState(t *testing.T) {
+	defer withTempHome(t)()
+
+	clic, _ := getClicHome()
+	assertEqual(t, nil, os.MkdirAll(clic, 0700))
+
+	now := time.Now()
+	recordDetached(DetachedContainer{Name: "clic-awslogs-1", Command: "awslogs@0.11", Started: now.Add(-time.Minute)})
+	recordDetached(DetachedContainer{Name: "clic-awslogs-2", Command: "awslogs@0.11", Started: now})
+	recordDetached(DetachedContainer{Name: "clic-mock-3", Command: "mock", Started: now})
+
+	s, err := loadState()
+	assertEqual(t, nil, err)
+	assertEqual(t, "clic-awslogs-2", s.latestDetached(parseCommand("awslogs")).Name)
+
+	forgetDetached("clic-awslogs-2")
+	s, _ = loadState()
+	assertEqual(t, 2, len(s.Detached))
+	assertEqual(t, "clic-awslogs-1", s.latestDetached(parseCommand("awslogs@0.11")).Name)
+	assertEqual(t, true, s.latestDetached(parseCommand("jq")) == nil)
+}
+
+func TestDetachedContainerName(t *testing.T) {
+	a, err := detachedContainerName(RepoCommand{Name: "awslogs@0.11"}, "")
+	assertEqual(t, nil, err)
+	b, _ := detachedContainerName(RepoCommand{Name: "awslogs@0.11"}, "")
+
+	assertEqual(t, true, strings.HasPrefix(a, "clic-awslogs-0.11-"))
+	assertEqual(t, len("clic-awslogs-0.11-")+8, len(a))
+	assertEqual(t, false, a == b)
+}
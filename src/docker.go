@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// imageConfig The parts of docker image inspect that clic cares about
+type imageConfig struct {
+	Entrypoint []string
+	Cmd        []string
+	WorkingDir string
+	User       string
+	Labels     map[string]string
+}
+
+// inspectImage Returns the config of the given image, pulling it first if it
+// isn't available locally
+func inspectImage(img string) (imageConfig, error) {
+	var config imageConfig
+
+	if !imageExists(img) {
+		p := exec.Command("docker", "pull", img)
+		p.Stdout = os.Stderr
+		p.Stderr = os.Stderr
+		if err := p.Run(); err != nil {
+			return config, fmt.Errorf("Unable to pull %s: %v", img, err)
+		}
+	}
+
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{json .Config}}", img).Output()
+	if err != nil {
+		return config, fmt.Errorf("Unable to inspect %s: %v", img, err)
+	}
+
+	err = json.Unmarshal(out, &config)
+	return config, err
+}
+
+// resolveArgv Returns the full command line the container would run, for
+// when the entrypoint has to be replaced by something else
+func resolveArgv(img string, entrypoint string, args []string) ([]string, error) {
+	var argv []string
+	if entrypoint > "" {
+		argv = append(argv, entrypoint)
+	} else {
+		config, err := inspectImage(img)
+		if err != nil {
+			return nil, err
+		}
+		argv = append(argv, config.Entrypoint...)
+		if len(args) == 0 {
+			argv = append(argv, config.Cmd...)
+		}
+	}
+	argv = append(argv, args...)
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("Unable to determine what to run in %s", img)
+	}
+	return argv, nil
+}
+
+// clicContainer A running container started by clic
+type clicContainer struct {
+	Name    string
+	Command string
+	Mode    string
+	Status  string
+}
+
+// listClicContainers Returns the running containers started by clic, and with
+// all also the ones that have exited but are kept, i.e. detached containers
+func listClicContainers(all bool) ([]clicContainer, error) {
+	format := fmt.Sprintf(`{{.Names}}\t{{.Label "%s"}}\t{{.Label "%s"}}\t{{.Status}}`, LabelCommand, LabelMode)
+	ps := []string{"ps", "--filter", "label=" + LabelCommand, "--format", format}
+	if all {
+		ps = append(ps, "-a")
+	}
+	out, err := exec.Command("docker", ps...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []clicContainer
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 4 {
+			continue
+		}
+		containers = append(containers, clicContainer{
+			Name:    parts[0],
+			Command: parts[1],
+			Mode:    parts[2],
+			Status:  parts[3],
+		})
+	}
+
+	return containers, nil
+}
+
+// matches Whether the container belongs to the given command or command@version
+func (c clicContainer) matches(cmd CommandVersion) bool {
+	if cmd.hasVersion {
+		return c.Command == cmd.toString()
+	}
+	return parseCommand(c.Command).command == cmd.command
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// dockerRunImport The result of converting a docker run command line
+type dockerRunImport struct {
+	Command RepoCommand
+
+	// Flags and arguments that clic has no way to represent
+	Unsupported []string
+}
+
+// Boolean flags of docker run, all other flags take a value
+var dockerRunBoolFlags = map[string]bool{
+	"-d": true, "--detach": true,
+	"-i": true, "--interactive": true,
+	"-t": true, "--tty": true,
+	"-P": true, "--publish-all": true,
+	"--rm":               true,
+	"--init":             true,
+	"--privileged":       true,
+	"--read-only":        true,
+	"--sig-proxy":        true,
+	"--no-healthcheck":   true,
+	"--oom-kill-disable": true,
+}
+
+// Host folder references which mean "the current directory"
+var pwdReferences = []string{"$PWD", "${PWD}", "$(pwd)", "`pwd`", "."}
+
+// Host folder references which mean "the home directory"
+var homeReferences = []string{"$HOME", "${HOME}", "~"}
+
+// splitShellWords Splits a command line into words the way a POSIX shell would
+// with respect to quoting. Variables and substitutions are left untouched.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	parens := 0
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '(':
+			parens++
+			word.WriteRune(r)
+			inWord = true
+		case r == ')':
+			parens--
+			word.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\n') && parens == 0:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("Unterminated quote or escape")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// parseDockerRunLine Converts a docker run command line, optionally wrapped in
+// a shell alias definition, into a repo command.
+func parseDockerRunLine(line string) (dockerRunImport, error) {
+	var result dockerRunImport
+
+	words, err := splitShellWords(line)
+	if err != nil {
+		return result, err
+	}
+
+	// alias name='docker run ...'
+	aliasName := ""
+	if len(words) == 2 && words[0] == "alias" {
+		parts := strings.SplitN(words[1], "=", 2)
+		if len(parts) != 2 {
+			return result, errors.New("Invalid alias definition")
+		}
+		aliasName = parts[0]
+		words, err = splitShellWords(parts[1])
+		if err != nil {
+			return result, err
+		}
+	}
+
+	switch {
+	case len(words) >= 2 && words[0] == "docker" && words[1] == "run":
+		words = words[2:]
+	case len(words) >= 3 && words[0] == "docker" && words[1] == "container" && words[2] == "run":
+		words = words[3:]
+	default:
+		return result, errors.New("Not a docker run command line")
+	}
+
+	cmd := RepoCommand{}
+	stdin := false
+	var volumes []string
+
+	i := 0
+	for ; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			break
+		}
+		if w == "--" {
+			i++
+			break
+		}
+
+		name := w
+		value := ""
+		hasValue := false
+
+		if strings.HasPrefix(w, "--") {
+			if eq := strings.Index(w, "="); eq > 0 {
+				name = w[:eq]
+				value = w[eq+1:]
+				hasValue = true
+			}
+		} else if len(w) > 2 {
+			// Combined short flags such as -it, or a short flag with its value
+			// attached such as -w/root
+			allBool := true
+			for _, c := range w[1:] {
+				if !dockerRunBoolFlags["-"+string(c)] {
+					allBool = false
+				}
+			}
+			if allBool {
+				for _, c := range w[1:] {
+					if c == 'i' {
+						stdin = true
+					} else if c != 't' {
+						result.Unsupported = append(result.Unsupported, "-"+string(c))
+					}
+				}
+				continue
+			}
+			name = w[:2]
+			value = w[2:]
+			hasValue = true
+		}
+
+		if dockerRunBoolFlags[name] {
+			switch name {
+			case "-i", "--interactive":
+				stdin = value != "false"
+			case "-t", "--tty", "--rm":
+				// clic detects tty mode itself and always removes containers
+			default:
+				result.Unsupported = append(result.Unsupported, w)
+			}
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(words) {
+				return result, fmt.Errorf("Missing value for %s", name)
+			}
+			i++
+			value = words[i]
+		}
+
+		switch name {
+		case "-v", "--volume":
+			volumes = append(volumes, value)
+		case "-w", "--workdir":
+			cmd.Workdir = value
+		case "--entrypoint":
+			cmd.Entrypoint = value
+		case "-e", "--env":
+			cmd.Env = append(cmd.Env, value)
+		case "-u", "--user":
+			cmd.User = value
+		default:
+			result.Unsupported = append(result.Unsupported, name+" "+value)
+		}
+	}
+
+	if i >= len(words) {
+		return result, errors.New("No image found in docker run command line")
+	}
+
+	cmd.Image = words[i]
+	if i+1 < len(words) {
+		result.Unsupported = append(result.Unsupported, "arguments: "+strings.Join(words[i+1:], " "))
+	}
+
+	if !stdin {
+		cmd.Stdin = StdInNever
+	}
+
+	// Recognize the mounts clic can manage itself
+	for _, v := range volumes {
+		parts := strings.SplitN(v, ":", 2)
+		if len(parts) == 2 && cmd.Workdir > "" && strings.TrimSuffix(parts[1], "/") == strings.TrimSuffix(cmd.Workdir, "/") {
+			if contains(pwdReferences, parts[0]) {
+				cmd.Mount = MountPwd
+				continue
+			}
+			if contains(homeReferences, parts[0]) {
+				cmd.Mount = MountAuto
+				continue
+			}
+		}
+		if strings.ContainsAny(v, "$`") || !strings.HasPrefix(v, "/") {
+			result.Unsupported = append(result.Unsupported, "-v "+v)
+			continue
+		}
+		cmd.Volumes = append(cmd.Volumes, v)
+	}
+
+	cmd.Name = aliasName
+	if cmd.Name == "" {
+		cmd.Name = commandNameFromImage(cmd.Image)
+	}
+
+	result.Command = cmd
+	return result, nil
+}
+
+// commandNameFromImage Derives a command@version name from an image reference,
+// i.e. stedolan/jq:1.6 becomes jq@1.6
+func commandNameFromImage(image string) string {
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+
+	tag := ""
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		tag = name[colon+1:]
+		name = name[:colon]
+	}
+
+	name = path.Base(name)
+	if tag > "" && tag != "latest" {
+		name = name + "@" + tag
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
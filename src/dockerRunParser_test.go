@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestParseDockerRunAlias(t *testing.T) {
+	imported, err := parseDockerRunLine(`alias jq='docker run -i --rm -v $PWD:/w -w /w stedolan/jq'`)
+	assertEqual(t, nil, err)
+
+	cmd := imported.Command
+	assertEqual(t, "jq", cmd.Name)
+	assertEqual(t, "stedolan/jq", cmd.Image)
+	assertEqual(t, "/w", cmd.Workdir)
+	assertEqual(t, MountPwd, cmd.Mount)
+	assertEqual(t, StdInEmpty, cmd.Stdin)
+	assertEqual(t, 0, len(cmd.Volumes))
+	assertEqual(t, 0, len(imported.Unsupported))
+}
+
+func TestParseDockerRunFlags(t *testing.T) {
+	imported, err := parseDockerRunLine(`docker run --rm -t -v "$HOME:/root" -w /root --entrypoint=/usr/local/bin/awslogs -v /etc/ssl:/etc/ssl:ro -e AWS_PROFILE alpine/awslogs:1 get`)
+	assertEqual(t, nil, err)
+
+	cmd := imported.Command
+	assertEqual(t, "awslogs@1", cmd.Name)
+	assertEqual(t, "alpine/awslogs:1", cmd.Image)
+	assertEqual(t, MountAuto, cmd.Mount)
+	assertEqual(t, "/usr/local/bin/awslogs", cmd.Entrypoint)
+	assertEqual(t, StdInNever, cmd.Stdin)
+	assertEqual(t, 1, len(cmd.Volumes))
+	assertEqual(t, "/etc/ssl:/etc/ssl:ro", cmd.Volumes[0])
+	assertEqual(t, 1, len(cmd.Env))
+	assertEqual(t, "AWS_PROFILE", cmd.Env[0])
+	assertEqual(t, 1, len(imported.Unsupported))
+	assertEqual(t, "arguments: get", imported.Unsupported[0])
+}
+
+func TestParseDockerRunUser(t *testing.T) {
+	imported, err := parseDockerRunLine(`docker run --rm -i -u 1000:1000 -e NODE_ENV=production --env=CI node:12`)
+	assertEqual(t, nil, err)
+
+	cmd := imported.Command
+	assertEqual(t, "1000:1000", cmd.User)
+	assertEqual(t, 2, len(cmd.Env))
+	assertEqual(t, "NODE_ENV=production", cmd.Env[0])
+	assertEqual(t, "CI", cmd.Env[1])
+	assertEqual(t, 0, len(imported.Unsupported))
+
+	imported, err = parseDockerRunLine(`docker run --user=node node:12`)
+	assertEqual(t, nil, err)
+	assertEqual(t, "node", imported.Command.User)
+}
+
+func TestParseDockerRunNotDocker(t *testing.T) {
+	_, err := parseDockerRunLine(`ls -la`)
+	assertEqual(t, true, err != nil)
+}
+
+func TestCommandNameFromImage(t *testing.T) {
+	assertEqual(t, "jq@1.6", commandNameFromImage("stedolan/jq:1.6"))
+	assertEqual(t, "terraform", commandNameFromImage("hashicorp/terraform:latest"))
+	assertEqual(t, "app", commandNameFromImage("localhost:5000/app"))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DockerOption What access to the host docker daemon the container gets
+type DockerOption string
+
+const (
+	// DockerSocket Mount the host docker socket
+	DockerSocket DockerOption = "socket"
+
+	// GrantDocker Consent recorded for commands with access to the docker daemon
+	GrantDocker = "docker"
+
+	dockerContainerSocket = "/var/run/docker.sock"
+)
+
+// determineDocker Returns the mounts, environment and groups that give the
+// container access to the host docker daemon
+func determineDocker(cmd RepoCommand) ([]string, map[string]string, []string, error) {
+	var volumes []string
+	env := make(map[string]string)
+	var groups []string
+
+	switch cmd.Docker {
+	case "":
+		return nil, nil, nil, nil
+	case DockerSocket:
+	default:
+		return nil, nil, nil, fmt.Errorf("Unknown docker option for %s: %s", cmd.Name, cmd.Docker)
+	}
+
+	host := os.Getenv("DOCKER_HOST")
+	if host > "" && !strings.HasPrefix(host, "unix://") {
+		// Remote daemon, nothing to mount
+		env["DOCKER_HOST"] = host
+		return volumes, env, groups, nil
+	}
+
+	sock := strings.TrimPrefix(host, "unix://")
+	if sock == "" {
+		sock = dockerContainerSocket
+	}
+	// docker would create a folder in place of a missing socket
+	info, err := os.Stat(sock)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	volumes = append(volumes, sock+":"+dockerContainerSocket)
+	env["DOCKER_HOST"] = "unix://" + dockerContainerSocket
+
+	// A user that may not be root needs the group that owns the socket
+	if !isRootUser(cmd.User) {
+		if gid, ok := fileGroup(info); ok {
+			groups = append(groups, strconv.Itoa(gid))
+		}
+	}
+
+	return volumes, env, groups, nil
+}
+
+// ensureConsent Asks the user before installing a command with root
+// equivalent access to the host
+func ensureConsent(d *Data, cmd RepoCommand, preapproved bool) error {
+	if cmd.Docker == "" || d.hasGrant(cmd.Name, GrantDocker) {
+		return nil
+	}
+
+	if !preapproved {
+		fmt.Printf("⚠ %s requests access to the docker daemon, which is equivalent to root access on this host.\n", cmd.Name)
+		fmt.Print("Allow? [y/N] ")
+
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return fmt.Errorf("Not installed: docker access for %s was not allowed", cmd.Name)
+		}
+	}
+	return nil
+}
+
+// recordConsent Saves the access allowed by ensureConsent, once the command is installed
+func recordConsent(d *Data, cmd RepoCommand) error {
+	if cmd.Docker == "" {
+		return nil
+	}
+	return d.grant(cmd.Name, GrantDocker)
+}
+
+// loadGrants The access allowed to the command on install
+func loadGrants(cmd RepoCommand) ([]string, error) {
+	if cmd.Docker == "" {
+		return nil, nil
+	}
+
+	d, err := loadData()
+	if err != nil {
+		return nil, err
+	}
+	return d.Grants[cmd.Name], nil
+}
+
+// requireConsent Refuses to run a command whose access wasn't allowed on install
+func requireConsent(cmd RepoCommand, grants []string) error {
+	if cmd.Docker == "" || contains(grants, GrantDocker) {
+		return nil
+	}
+	return fmt.Errorf("%s needs access to the docker daemon, run 'clic install %s' to allow it", cmd.Name, cmd.Name)
+}
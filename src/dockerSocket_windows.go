@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+// fileGroup Files have no owning group id on Windows
+func fileGroup(info os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// commandEnv The environment variables given to the container
+type commandEnv struct {
+	// Fixed Values that are safe to show on the command line
+	Fixed map[string]string
+
+	// Pass Names passed with -e NAME, docker takes the value from its own
+	// environment so it never appears on the command line
+	Pass []string
+
+	// File Interpolated values, given to docker with --env-file through a
+	// pipe, so they are neither on the command line nor in docker's own
+	// environment where a host variable of the same name would win
+	File []string
+}
+
+// determineEnv Resolves the env entries of the command, which are one of:
+//
+//	NAME        pass through the host variable
+//	PREFIX_*    pass through all host variables matching the pattern
+//	NAME=value  fixed value, which can reference host variables as $VAR or ${VAR}
+func determineEnv(cmd RepoCommand) (commandEnv, error) {
+	env := commandEnv{Fixed: make(map[string]string)}
+	seen := make(map[string]bool)
+
+	pass := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			env.Pass = append(env.Pass, name)
+		}
+	}
+
+	for _, e := range cmd.Env {
+		parts := strings.SplitN(e, "=", 2)
+		name := parts[0]
+
+		if len(parts) == 2 {
+			if !strings.Contains(parts[1], "$") {
+				env.Fixed[name] = parts[1]
+				continue
+			}
+			value := os.ExpandEnv(parts[1])
+			if strings.ContainsAny(value, "\r\n") {
+				return env, fmt.Errorf("Env value of %s for %s can not span lines", name, cmd.Name)
+			}
+			env.File = append(env.File, name+"="+value)
+			seen[name] = true
+			continue
+		}
+
+		if strings.ContainsAny(name, "*?[") {
+			if _, err := path.Match(name, ""); err != nil {
+				return env, fmt.Errorf("Invalid env pattern for %s: %s", cmd.Name, name)
+			}
+			var matches []string
+			for _, kv := range os.Environ() {
+				k := strings.SplitN(kv, "=", 2)[0]
+				if ok, _ := path.Match(name, k); ok {
+					matches = append(matches, k)
+				}
+			}
+			sort.Strings(matches)
+			for _, m := range matches {
+				pass(m)
+			}
+			continue
+		}
+
+		if _, ok := os.LookupEnv(name); ok {
+			pass(name)
+		}
+	}
+
+	return env, nil
+}
+
+// envNames Returns the names of NAME=value pairs
+func envNames(env []string) []string {
+	var names []string
+	for _, e := range env {
+		names = append(names, strings.SplitN(e, "=", 2)[0])
+	}
+	return names
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Where docker reads the env file from, the first of the extra files
+const envFileDescriptor = "/dev/fd/3"
+
+// attachEnvFile Gives the process the environment lines on envFileDescriptor.
+// The returned function closes the parent's end once the process is done.
+func attachEnvFile(p *exec.Cmd, env []string) (func(), error) {
+	if len(env) == 0 {
+		return func() {}, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p.ExtraFiles = []*os.File{r}
+
+	go func() {
+		w.WriteString(strings.Join(env, "\n") + "\n")
+		w.Close()
+	}()
+
+	return func() { r.Close() }, nil
+}
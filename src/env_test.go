@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestDetermineEnv(t *testing.T) {
+	os.Setenv("CLIC_TEST_PROFILE", "dev")
+	os.Setenv("CLIC_TEST_VAR_a", "1")
+	os.Setenv("CLIC_TEST_VAR_b", "2")
+	os.Setenv("CLIC_TEST_HOME", "/home/me")
+	defer os.Unsetenv("CLIC_TEST_PROFILE")
+	defer os.Unsetenv("CLIC_TEST_VAR_a")
+	defer os.Unsetenv("CLIC_TEST_VAR_b")
+	defer os.Unsetenv("CLIC_TEST_HOME")
+
+	env, err := determineEnv(RepoCommand{Env: []string{
+		"CLIC_TEST_PROFILE",
+		"CLIC_TEST_MISSING",
+		"CLIC_TEST_VAR_*",
+		"FIXED=value",
+		"KUBECONFIG=${CLIC_TEST_HOME}/.kube/config",
+	}})
+	assertEqual(t, nil, err)
+
+	assertEqual(t, 3, len(env.Pass))
+	assertEqual(t, "CLIC_TEST_PROFILE", env.Pass[0])
+	assertEqual(t, "CLIC_TEST_VAR_a", env.Pass[1])
+	assertEqual(t, "CLIC_TEST_VAR_b", env.Pass[2])
+
+	assertEqual(t, "value", env.Fixed["FIXED"])
+
+	assertEqual(t, 1, len(env.File))
+	assertEqual(t, "KUBECONFIG=/home/me/.kube/config", env.File[0])
+}
+
+func TestInterpolatedEnvWinsOverHost(t *testing.T) {
+	os.Setenv("CLIC_TEST_HOME", "/home/me")
+	os.Setenv("CLIC_TEST_KUBECONFIG", "/host/config")
+	defer os.Unsetenv("CLIC_TEST_HOME")
+	defer os.Unsetenv("CLIC_TEST_KUBECONFIG")
+
+	env, err := determineEnv(RepoCommand{Env: []string{
+		"CLIC_TEST_KUBECONFIG=${CLIC_TEST_HOME}/.kube/config",
+		"CLIC_TEST_KUBECONFIG",
+	}})
+	assertEqual(t, nil, err)
+
+	// The host value is not passed, and docker reads the
+	// interpolated one from the pipe, not its environment
+	assertEqual(t, 0, len(env.Pass))
+
+	p := exec.Command("/bin/sh", "-c", "echo $CLIC_TEST_KUBECONFIG; cat "+envFileDescriptor)
+	closeEnvFile, err := attachEnvFile(p, env.File)
+	assertEqual(t, nil, err)
+	defer closeEnvFile()
+
+	out, err := p.Output()
+	assertEqual(t, nil, err)
+	assertEqual(t, "/host/config\nCLIC_TEST_KUBECONFIG=/home/me/.kube/config\n", string(out))
+
+	// Lines can't be split in an env file
+	os.Setenv("CLIC_TEST_MULTI", "a\nb")
+	defer os.Unsetenv("CLIC_TEST_MULTI")
+	_, err = determineEnv(RepoCommand{Name: "x", Env: []string{"MULTI=${CLIC_TEST_MULTI}"}})
+	assertEqual(t, "Env value of MULTI for x can not span lines", err.Error())
+}
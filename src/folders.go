@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var userHomeDir string
@@ -43,6 +44,42 @@ func getClicBinPath(file string) (string, error) {
 	return filepath.Join(clic, "bin", file), nil
 }
 
+func getClicBinariesPath(elem ...string) (string, error) {
+	clic, err := getClicHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{clic, "binaries"}, elem...)...), nil
+}
+
+func getClicPlanPath(name string) (string, error) {
+	clic, err := getClicHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(clic, "plans", name+".json"), nil
+}
+
+func getStatePath() (string, error) {
+	clic, err := getClicHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(clic, "state.yaml"), nil
+}
+
+func getPolicyPath() (string, error) {
+	clic, err := getClicHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(clic, "policy.yaml"), nil
+}
+
 func getRepoPath() (string, error) {
 	clic, err := getClicHome()
 	if err != nil {
@@ -52,6 +89,15 @@ func getRepoPath() (string, error) {
 	return filepath.Join(clic, "repo", "repo.yaml"), nil
 }
 
+func getLocalRepoPath() (string, error) {
+	clic, err := getClicHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(clic, "local.yaml"), nil
+}
+
 func getDataPath() (string, error) {
 	clic, err := getClicHome()
 	if err != nil {
@@ -70,6 +116,19 @@ func getDockerfilePath(dockerfile string) (string, error) {
 	return filepath.Join(clic, "repo", dockerfile), nil
 }
 
+// expandHome Expands a leading ~ to the user's home folder
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+
+	home, err := getUserHome()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
+
 func mkdir(f string) (bool, error) {
 	if _, err := os.Stat(f); err != nil {
 		if os.IsNotExist(err) {
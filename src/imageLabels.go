@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Image labels that describe how to run the image with clic
+const (
+	LabelWorkdir    = "io.clic.workdir"
+	LabelMount      = "io.clic.mount"
+	LabelEntrypoint = "io.clic.entrypoint"
+	LabelStdin      = "io.clic.stdin"
+	LabelTty        = "io.clic.tty"
+	LabelFixttydims = "io.clic.fixttydims"
+)
+
+func hasClicLabels(labels map[string]string) bool {
+	for k := range labels {
+		if strings.HasPrefix(k, "io.clic.") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyImageLabels Fills in the fields left unset on the command from the
+// image labels. Fields set on the command always win.
+func applyImageLabels(cmd RepoCommand, labels map[string]string) RepoCommand {
+	if v, ok := labels[LabelWorkdir]; ok && cmd.Workdir == "" {
+		cmd.Workdir = v
+	}
+	if v, ok := labels[LabelMount]; ok && cmd.Mount == "" {
+		cmd.Mount = MountOption(v)
+	}
+	if v, ok := labels[LabelEntrypoint]; ok && cmd.Entrypoint == "" {
+		cmd.Entrypoint = v
+	}
+	if v, ok := labels[LabelStdin]; ok && cmd.Stdin == StdInEmpty {
+		cmd.Stdin = StdInOption(v)
+	}
+	if v, ok := labels[LabelTty]; ok && cmd.Tty == "" {
+		cmd.Tty = TtyOption(v)
+	}
+	if v, ok := labels[LabelFixttydims]; ok && !cmd.Fixttydims {
+		cmd.Fixttydims, _ = strconv.ParseBool(v)
+	}
+	return cmd
+}
+
+// resolveImageLabels Reads the labels of the image once, for commands that
+// fall back to them, so they don't have to be inspected on every run
+func resolveImageLabels(cmd RepoCommand) (RepoCommand, error) {
+	if !cmd.Labels || cmd.Image == "" {
+		return cmd, nil
+	}
+
+	config, err := inspectImage(cmd.Image)
+	if err != nil {
+		return cmd, err
+	}
+	cmd = applyImageLabels(cmd, config.Labels)
+	cmd.Labels = false
+	return cmd, nil
+}
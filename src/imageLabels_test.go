@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestApplyImageLabels(t *testing.T) {
+	labels := map[string]string{
+		LabelWorkdir:    "/data",
+		LabelMount:      "project",
+		LabelEntrypoint: "/usr/bin/tool",
+		LabelStdin:      "always",
+		LabelTty:        "never",
+		LabelFixttydims: "true",
+	}
+
+	cmd := applyImageLabels(RepoCommand{Image: "tool"}, labels)
+	assertEqual(t, "/data", cmd.Workdir)
+	assertEqual(t, MountProject, cmd.Mount)
+	assertEqual(t, "/usr/bin/tool", cmd.Entrypoint)
+	assertEqual(t, StdInAlways, cmd.Stdin)
+	assertEqual(t, TtyNever, cmd.Tty)
+	assertEqual(t, true, cmd.Fixttydims)
+
+	// Options set on the command win over the labels
+	cmd = applyImageLabels(RepoCommand{Image: "tool", Workdir: "/src", Mount: MountPwd, Tty: TtyAlways}, labels)
+	assertEqual(t, "/src", cmd.Workdir)
+	assertEqual(t, MountPwd, cmd.Mount)
+	assertEqual(t, TtyAlways, cmd.Tty)
+	assertEqual(t, "/usr/bin/tool", cmd.Entrypoint)
+
+	cmd = applyImageLabels(RepoCommand{Image: "tool"}, nil)
+	assertEqual(t, "", cmd.Workdir)
+	assertEqual(t, false, cmd.Fixttydims)
+}
+
+func TestResolveImageLabelsWithoutLabels(t *testing.T) {
+	// Nothing to inspect, so docker isn't needed
+	cmd, err := resolveImageLabels(RepoCommand{Image: "tool", Workdir: "/src"})
+	assertEqual(t, nil, err)
+	assertEqual(t, "/src", cmd.Workdir)
+}
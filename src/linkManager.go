@@ -26,12 +26,22 @@ func link(cmd CommandVersion) error {
 	}
 
 	err = os.Symlink(clic, linkPath)
+	if err != nil {
+		return err
+	}
 
-	if err == nil {
-		fmt.Println("✓ Created symlink:", linkPath)
+	fmt.Println("✓ Created symlink:", linkPath)
+
+	// Precompile what the symlink will run
+	resolved, err := resolveCommand(cmd)
+	if err != nil {
+		return err
+	}
+	if resolved != nil {
+		return compilePlan(cmd.toString(), *resolved)
 	}
 
-	return err
+	return nil
 }
 
 func unlink(cmd CommandVersion) error {
@@ -50,5 +60,5 @@ func unlink(cmd CommandVersion) error {
 		fmt.Println("✓ Removed symlink:", linkPath)
 	}
 
-	return nil
+	return removePlan(cmd.toString())
 }
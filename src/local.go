@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadLocalRepo Loads the user's own command definitions. These are kept
+// apart from the fetched repo so that a fetch never overwrites them.
+func loadLocalRepo() (Repo, error) {
+	var repo Repo
+
+	f, err := getLocalRepoPath()
+	if err != nil {
+		return repo, err
+	}
+
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		if os.IsNotExist(err) {
+			repo.Commands = make(map[string]RepoCommand)
+			return repo, nil
+		}
+		return repo, err
+	}
+
+	err = yaml.Unmarshal(data, &repo)
+	if err != nil {
+		return repo, err
+	}
+
+	if repo.Commands == nil {
+		repo.Commands = make(map[string]RepoCommand)
+	}
+
+	for k, v := range repo.Commands {
+		v.Name = k
+		repo.Commands[k] = v
+	}
+
+	return repo, nil
+}
+
+func (r *Repo) saveLocal() error {
+	f, err := getLocalRepoPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(*r)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, data, 0600)
+}
+
+// saveLocalCommand Adds or replaces a local command definition
+func saveLocalCommand(cmd RepoCommand) error {
+	local, err := loadLocalRepo()
+	if err != nil {
+		return err
+	}
+
+	local.Commands[cmd.Name] = cmd
+	return local.saveLocal()
+}
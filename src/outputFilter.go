@@ -0,0 +1,182 @@
+package main
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a possible partial match is held back before it is written as is
+const outputFlushDelay = 20 * time.Millisecond
+
+// pathMapping A container folder and the host folder mounted there
+type pathMapping struct {
+	Container string
+	Host      string
+}
+
+// determinePathMappings Turns the volumes into mappings, longest container path first
+func determinePathMappings(volumes []string) []pathMapping {
+	var mappings []pathMapping
+	for _, v := range volumes {
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 || !strings.HasPrefix(parts[0], "/") || parts[0] == parts[1] {
+			continue
+		}
+		mappings = append(mappings, pathMapping{
+			Container: strings.TrimSuffix(parts[1], "/"),
+			Host:      strings.TrimSuffix(parts[0], "/"),
+		})
+	}
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return len(mappings[i].Container) > len(mappings[j].Container)
+	})
+	return mappings
+}
+
+// isPathByte Characters that can be part of a path, so a prefix
+// next to them is not a whole path segment
+func isPathByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' ||
+		strings.IndexByte("/._-~+@", b) >= 0
+}
+
+// pathMapper Writer that rewrites container paths to host paths. Output that
+// may be the start of a container path is held back until it is complete, or
+// for a short while. In tty mode escape sequences are written untouched.
+type pathMapper struct {
+	out      io.Writer
+	mappings []pathMapping
+	tty      bool
+
+	mu    sync.Mutex
+	held  []byte
+	last  byte
+	timer *time.Timer
+}
+
+func newPathMapper(out io.Writer, mappings []pathMapping, tty bool) *pathMapper {
+	return &pathMapper{out: out, mappings: mappings, tty: tty}
+}
+
+func (m *pathMapper) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+
+	m.held = append(m.held, p...)
+	if err := m.process(false); err != nil {
+		return 0, err
+	}
+
+	if len(m.held) > 0 {
+		m.timer = time.AfterFunc(outputFlushDelay, func() { m.Flush() })
+	}
+	return len(p), nil
+}
+
+// Flush Writes whatever is held back
+func (m *pathMapper) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.process(true)
+}
+
+// process Writes the held output up to where a match can't be decided yet.
+// When final, everything is decided with what is there.
+func (m *pathMapper) process(final bool) error {
+	data := m.held
+	var out []byte
+	i := 0
+
+scan:
+	for i < len(data) {
+		if m.tty && data[i] == 0x1b {
+			end := escapeSequenceEnd(data[i:])
+			if end < 0 {
+				if !final {
+					break
+				}
+				end = len(data) - i
+			}
+			out = append(out, data[i:i+end]...)
+			i += end
+			m.last = 0
+			continue
+		}
+
+		if data[i] == '/' && !isPathByte(m.last) {
+			for _, mp := range m.mappings {
+				rest := data[i:]
+				if len(rest) < len(mp.Container) {
+					if !final && strings.HasPrefix(mp.Container, string(rest)) {
+						break scan
+					}
+					continue
+				}
+				if string(rest[:len(mp.Container)]) != mp.Container {
+					continue
+				}
+
+				j := i + len(mp.Container)
+				if j == len(data) && !final {
+					break scan
+				}
+				if j == len(data) || data[j] == '/' || !isPathByte(data[j]) {
+					out = append(out, mp.Host...)
+					i = j
+					m.last = '/'
+					continue scan
+				}
+			}
+		}
+
+		out = append(out, data[i])
+		m.last = data[i]
+		i++
+	}
+
+	m.held = append(m.held[:0], data[i:]...)
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := m.out.Write(out)
+	return err
+}
+
+// escapeSequenceEnd Length of the escape sequence at the start of data,
+// or -1 when it is not complete yet
+func escapeSequenceEnd(data []byte) int {
+	if len(data) < 2 {
+		return -1
+	}
+
+	switch data[1] {
+	case '[':
+		// CSI, ends with a byte in @ to ~
+		for i := 2; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return -1
+	case ']', 'P', '_', '^':
+		// OSC and other strings, end with BEL or ESC \
+		for i := 2; i < len(data); i++ {
+			if data[i] == 0x07 {
+				return i + 1
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return -1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPathMapper(t *testing.T) {
+	mappings := determinePathMappings([]string{
+		"/home/me:/root",
+		"/home/me/project:/root/project:ro",
+		"/srv:/srv",
+	})
+	assertEqual(t, 2, len(mappings))
+	assertEqual(t, "/root/project", mappings[0].Container)
+
+	var out bytes.Buffer
+	m := newPathMapper(&out, mappings, false)
+
+	// Split across writes, the prefix is held back until complete
+	m.Write([]byte("Error: /ro"))
+	m.Write([]byte("ot/src/main.tf:12 and /rootfs/x, /a/root/b, \"/root\"\n/root/project/x /ro"))
+	m.Flush()
+
+	assertEqual(t, "Error: /home/me/src/main.tf:12 and /rootfs/x, /a/root/b, \"/home/me\"\n/home/me/project/x /ro", out.String())
+}
+
+func TestPathMapperTty(t *testing.T) {
+	mappings := []pathMapping{{Container: "/root", Host: "/home/me"}}
+
+	var out bytes.Buffer
+	m := newPathMapper(&out, mappings, true)
+
+	m.Write([]byte("\x1b]0;/root\x07\x1b[3"))
+	m.Write([]byte("1m/root/x\x1b[0m"))
+	m.Flush()
+
+	assertEqual(t, "\x1b]0;/root\x07\x1b[31m/home/me/x\x1b[0m", out.String())
+}
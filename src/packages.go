@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// Package managers a generated Dockerfile can install with
+const (
+	PackageManagerApk = "apk"
+	PackageManagerApt = "apt"
+)
+
+// determinePackageManager The package manager given on the command, or else
+// apk for the official alpine image and the -alpine variants of other
+// official images, apt for all others
+func determinePackageManager(cmd RepoCommand) (string, error) {
+	switch cmd.PackageManager {
+	case PackageManagerApk, PackageManagerApt:
+		return cmd.PackageManager, nil
+	case "":
+	default:
+		return "", fmt.Errorf("Unknown package manager for %s: %s", cmd.Name, cmd.PackageManager)
+	}
+
+	name, tag := cmd.Base, ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	name = strings.TrimPrefix(name, "library/")
+
+	if name == "alpine" {
+		return PackageManagerApk, nil
+	}
+	if !strings.Contains(name, "/") {
+		for _, part := range strings.Split(tag, "-") {
+			if strings.HasPrefix(part, "alpine") {
+				return PackageManagerApk, nil
+			}
+		}
+	}
+	return PackageManagerApt, nil
+}
+
+// generateDockerfile Creates the Dockerfile for a command defined by a base
+// image and a list of packages
+func generateDockerfile(cmd RepoCommand) (string, error) {
+	manager, err := determinePackageManager(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "FROM %s\n", cmd.Base)
+
+	switch {
+	case len(cmd.Packages) == 0:
+		// Nothing to install
+	case manager == PackageManagerApk:
+		b.WriteString("RUN apk --no-cache add")
+		for _, p := range cmd.Packages {
+			b.WriteString(" \\\n  " + p)
+		}
+		b.WriteString("\n")
+	default:
+		b.WriteString("RUN apt-get update && apt-get install -y --no-install-recommends")
+		for _, p := range cmd.Packages {
+			b.WriteString(" \\\n  " + p)
+		}
+		b.WriteString(" \\\n  && rm -rf /var/lib/apt/lists/*\n")
+	}
+
+	return b.String(), nil
+}
+
+// generatedImageName Names the image by the content of its Dockerfile so
+// that any change to the package list results in a new build
+func generatedImageName(cmd RepoCommand, dockerfile string) string {
+	hash := sha256.Sum256([]byte(dockerfile))
+	return fmt.Sprintf("clic-%s:%x", strings.Replace(cmd.Name, "@", "-", -1), hash[:6])
+}
@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestDeterminePackageManager(t *testing.T) {
+	tests := []struct {
+		base    string
+		manager string
+		want    string
+	}{
+		{"alpine:3.10", "", PackageManagerApk},
+		{"alpine", "", PackageManagerApk},
+		{"library/alpine:3.10", "", PackageManagerApk},
+		{"python:3-alpine", "", PackageManagerApk},
+		{"node:12-alpine3.11", "", PackageManagerApk},
+		{"debian:buster-slim", "", PackageManagerApt},
+		{"myorg/alpine-tools", "", PackageManagerApt},
+		{"myorg/tools:alpine", "", PackageManagerApt},
+		{"registry:5000/tools", "", PackageManagerApt},
+		{"myorg/alpine-tools", PackageManagerApk, PackageManagerApk},
+	}
+
+	for _, test := range tests {
+		got, err := determinePackageManager(RepoCommand{Base: test.base, PackageManager: test.manager})
+		assertEqual(t, nil, err)
+		assertEqual(t, test.want, got)
+	}
+
+	_, err := determinePackageManager(RepoCommand{Name: "jq@1.6", Base: "alpine", PackageManager: "yum"})
+	assertEqual(t, "Unknown package manager for jq@1.6: yum", err.Error())
+}
+
+func TestGenerateDockerfile(t *testing.T) {
+	tests := []struct {
+		cmd  RepoCommand
+		want string
+	}{
+		{
+			RepoCommand{Base: "alpine:3.10", Packages: []string{"jq=1.6-r0", "curl"}},
+			"FROM alpine:3.10\n" +
+				"RUN apk --no-cache add \\\n  jq=1.6-r0 \\\n  curl\n",
+		},
+		{
+			RepoCommand{Base: "debian:buster-slim", Packages: []string{"jq"}},
+			"FROM debian:buster-slim\n" +
+				"RUN apt-get update && apt-get install -y --no-install-recommends \\\n  jq \\\n  && rm -rf /var/lib/apt/lists/*\n",
+		},
+		{
+			RepoCommand{Base: "alpine:3.10"},
+			"FROM alpine:3.10\n",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := generateDockerfile(test.cmd)
+		assertEqual(t, nil, err)
+		assertEqual(t, test.want, got)
+	}
+}
+
+func TestDetermineImageBase(t *testing.T) {
+	// A base without packages is run as is
+	img, buildCmd, err := determineImage(RepoCommand{Name: "tfcheck@1", Base: "hashicorp/terraform:0.12.24"})
+	assertEqual(t, nil, err)
+	assertEqual(t, "hashicorp/terraform:0.12.24", img)
+	assertEqual(t, true, buildCmd == nil)
+
+	img, buildCmd, err = determineImage(RepoCommand{Name: "jq@1.6", Base: "alpine:3.10", Packages: []string{"jq"}})
+	assertEqual(t, nil, err)
+	assertEqual(t, true, buildCmd != nil)
+	assertEqual(t, "FROM alpine:3.10\nRUN apk --no-cache add \\\n  jq\n", buildCmd.StdinData)
+	assertEqual(t, img, buildCmd.Args[2])
+
+	_, _, err = determineImage(RepoCommand{Name: "jq@1.6", Packages: []string{"jq"}})
+	assertEqual(t, "Command jq@1.6 lists packages without a base image", err.Error())
+}
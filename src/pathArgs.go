@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Where host folders of path arguments are mounted in the container
+const pathArgsContainerDir = "/clic/host"
+
+// Never mounted for a path argument
+var pathArgsDefaultDeny = []string{"/proc", "/sys", "/dev", "/run", "/var/run"}
+
+// PathArgsOption Mount the folders of host paths given as arguments that
+// are outside of the mounts, and rewrite the arguments to the container paths
+type PathArgsOption struct {
+	Enabled bool `yaml:",omitempty"`
+
+	// Allow and Deny Host folders, or glob patterns, that may or may never be mounted
+	Allow []string `yaml:",omitempty"`
+	Deny  []string `yaml:",omitempty"`
+}
+
+// UnmarshalYAML Accepts pathArgs: true, or the rules which imply enabled
+func (p *PathArgsOption) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var b bool
+	if err := unmarshal(&b); err == nil {
+		*p = PathArgsOption{Enabled: b}
+		return nil
+	}
+
+	var rules struct {
+		Enabled *bool
+		Allow   []string
+		Deny    []string
+	}
+	if err := unmarshal(&rules); err != nil {
+		return err
+	}
+
+	*p = PathArgsOption{Enabled: true, Allow: rules.Allow, Deny: rules.Deny}
+	if rules.Enabled != nil {
+		p.Enabled = *rules.Enabled
+	}
+	return nil
+}
+
+// ruleMatches Whether the path is the rule folder, under it, or matches it as a pattern
+func ruleMatches(rule string, p string) bool {
+	rule = filepath.Clean(expandHome(rule))
+	if p == rule || strings.HasPrefix(p, rule+"/") {
+		return true
+	}
+	ok, _ := filepath.Match(rule, p)
+	return ok
+}
+
+func (p PathArgsOption) allowed(hostPath string) bool {
+	for _, r := range append(pathArgsDefaultDeny, p.Deny...) {
+		if ruleMatches(r, hostPath) {
+			return false
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, r := range p.Allow {
+		if ruleMatches(r, hostPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedWithin Whether a deny rule is inside the folder, which means
+// mounting the folder would expose it. For a pattern the folder part
+// before the first wildcard is used.
+func (p PathArgsOption) deniedWithin(dir string) bool {
+	for _, r := range append(pathArgsDefaultDeny, p.Deny...) {
+		r = filepath.Clean(expandHome(r))
+		if i := strings.IndexAny(r, "*?["); i >= 0 {
+			r = filepath.Dir(r[:i] + "x")
+		}
+		if strings.HasPrefix(r, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// toContainerPath Maps a host path through the volumes, if it is in one of them
+func toContainerPath(volumes []string, hostPath string) (string, bool) {
+	for _, v := range volumes {
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 || !strings.HasPrefix(parts[0], "/") {
+			continue
+		}
+		rel, err := filepath.Rel(parts[0], hostPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		return path.Join(parts[1], rel), true
+	}
+	return "", false
+}
+
+// rewritePathArgs Finds arguments, including the value of --flag=path, that are
+// host paths outside of the volumes. Their folders are mounted and the arguments
+// rewritten to point to the container. Absolute paths inside a volume are
+// rewritten to where that volume is in the container.
+func rewritePathArgs(opt PathArgsOption, args []string, volumes []string, cwd string) ([]string, []string) {
+	var rewritten []string
+	var extraVolumes []string
+	mounted := make(map[string]bool)
+
+	for _, a := range args {
+		prefix, value := "", a
+		if strings.HasPrefix(a, "-") {
+			eq := strings.Index(a, "=")
+			if eq < 0 {
+				rewritten = append(rewritten, a)
+				continue
+			}
+			prefix, value = a[:eq+1], a[eq+1:]
+		}
+
+		containerPath, ok := mapPathArg(opt, value, volumes, cwd, mounted, &extraVolumes)
+		if ok {
+			rewritten = append(rewritten, prefix+containerPath)
+		} else {
+			rewritten = append(rewritten, a)
+		}
+	}
+
+	return rewritten, extraVolumes
+}
+
+func mapPathArg(opt PathArgsOption, value string, volumes []string, cwd string, mounted map[string]bool, extraVolumes *[]string) (string, bool) {
+	absolute := strings.HasPrefix(value, "/")
+	escaping := value == ".." || strings.HasPrefix(value, "../") || strings.Contains(value, "/../")
+	if !absolute && !escaping {
+		return "", false
+	}
+
+	hostPath := value
+	if !absolute {
+		hostPath = filepath.Join(cwd, value)
+	}
+	hostPath = filepath.Clean(hostPath)
+
+	// Only what exists, or could be created, on the host
+	if _, err := os.Stat(filepath.Dir(hostPath)); err != nil {
+		return "", false
+	}
+
+	if p, ok := toContainerPath(volumes, hostPath); ok {
+		if !absolute {
+			// Relative paths inside a volume work as they are
+			return "", false
+		}
+		return p, true
+	}
+
+	info, statErr := os.Stat(hostPath)
+	dir := filepath.Dir(hostPath)
+	if statErr == nil && info.IsDir() {
+		dir = hostPath
+	}
+	if dir == "/" || !opt.allowed(hostPath) || !opt.allowed(dir) {
+		return "", false
+	}
+
+	// A folder holding something denied is not mounted, only an existing file
+	mount := dir
+	if opt.deniedWithin(dir) {
+		if statErr != nil || info.IsDir() {
+			return "", false
+		}
+		mount = hostPath
+	}
+
+	if !mounted[mount] {
+		mounted[mount] = true
+		*extraVolumes = append(*extraVolumes, mount+":"+path.Join(pathArgsContainerDir, mount))
+	}
+	return path.Join(pathArgsContainerDir, hostPath), true
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRewritePathArgs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "clic-pathargs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	home := filepath.Join(tmp, "home")
+	project := filepath.Join(home, "project")
+	shared := filepath.Join(tmp, "shared")
+	secret := filepath.Join(tmp, "secret")
+	os.MkdirAll(project, 0700)
+	os.MkdirAll(shared, 0700)
+	os.MkdirAll(secret, 0700)
+	ioutil.WriteFile(filepath.Join(shared, "prod.tfvars"), nil, 0600)
+
+	volumes := []string{project + ":/root"}
+	opt := PathArgsOption{Enabled: true, Deny: []string{secret}}
+
+	args, extra := rewritePathArgs(opt, []string{
+		"plan",
+		"-var-file=" + filepath.Join(shared, "prod.tfvars"),
+		filepath.Join(project, "main.tf"),
+		"../../shared/prod.tfvars",
+		"./local.tf",
+		filepath.Join(secret, "key"),
+		"--verbose",
+	}, volumes, project)
+
+	assertEqual(t, "plan", args[0])
+	assertEqual(t, "-var-file=/clic/host"+shared+"/prod.tfvars", args[1])
+	assertEqual(t, "/root/main.tf", args[2])
+	assertEqual(t, "/clic/host"+shared+"/prod.tfvars", args[3])
+	assertEqual(t, "./local.tf", args[4])
+	assertEqual(t, filepath.Join(secret, "key"), args[5])
+	assertEqual(t, "--verbose", args[6])
+
+	assertEqual(t, 1, len(extra))
+	assertEqual(t, shared+":/clic/host"+shared, extra[0])
+}
+
+func TestPathArgsDeniedWithinMount(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "clic-pathargs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	home := filepath.Join(tmp, "home")
+	project := filepath.Join(home, "project")
+	os.MkdirAll(project, 0700)
+	os.MkdirAll(filepath.Join(home, ".ssh"), 0700)
+	ioutil.WriteFile(filepath.Join(home, "notes.txt"), nil, 0600)
+
+	volumes := []string{project + ":/root"}
+	opt := PathArgsOption{Enabled: true, Deny: []string{filepath.Join(home, ".ssh")}}
+
+	// Home holds .ssh, so only the file is mounted, and nothing for
+	// a file that doesn't exist yet or the folder itself
+	args, extra := rewritePathArgs(opt, []string{"../notes.txt", "../new.txt", ".."}, volumes, project)
+	assertEqual(t, "/clic/host"+home+"/notes.txt", args[0])
+	assertEqual(t, "../new.txt", args[1])
+	assertEqual(t, "..", args[2])
+	assertEqual(t, 1, len(extra))
+	assertEqual(t, home+"/notes.txt:/clic/host"+home+"/notes.txt", extra[0])
+
+	// Same for a pattern
+	opt = PathArgsOption{Enabled: true, Deny: []string{filepath.Join(home, ".ssh", "id_*")}}
+	_, extra = rewritePathArgs(opt, []string{"../notes.txt"}, volumes, project)
+	assertEqual(t, home+"/notes.txt:/clic/host"+home+"/notes.txt", extra[0])
+}
+
+func TestUnmarshalPathArgs(t *testing.T) {
+	var cmd RepoCommand
+	assertEqual(t, nil, yaml.Unmarshal([]byte("pathArgs: true"), &cmd))
+	assertEqual(t, true, cmd.PathArgs.Enabled)
+
+	cmd = RepoCommand{}
+	assertEqual(t, nil, yaml.Unmarshal([]byte("pathArgs:\n  deny: [/etc]"), &cmd))
+	assertEqual(t, true, cmd.PathArgs.Enabled)
+	assertEqual(t, "/etc", cmd.PathArgs.Deny[0])
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Labels put on containers started by clic
+const (
+	LabelCommand     = "clic.command"
+	LabelMode        = "clic.mode"
+	LabelIdleTimeout = "clic.idle-timeout"
+)
+
+const (
+	// ModePersistent Long lived container that invocations are exec'd into
+	ModePersistent = "persistent"
+
+	defaultIdleTimeout = 10 * time.Minute
+
+	// How often the container checks whether it is in use
+	idleCheckInterval = 5 * time.Second
+
+	// How long to wait for a container started by a concurrent invocation
+	sharedStartTimeout = 30 * time.Second
+)
+
+// Error of docker run when a container with the name exists already
+var nameConflictPattern = regexp.MustCompile(`container name "[^"]*" is already in use`)
+
+// Keeps the container alive until it has been idle for the timeout, and stops
+// promptly on docker stop. Any process besides init, the script itself and
+// zombies is an invocation, so the idle time starts when the last one finishes. The idle
+// seconds are printed whenever they change, for clic ps to read from the logs.
+const keepAliveScript = `trap 'exit 0' TERM INT
+idle=0
+while [ "$idle" -lt %d ]; do
+  sleep %d & wait $!
+  busy=
+  for p in /proc/[0-9]*; do
+    case "${p#/proc/}" in 1|$$) continue ;; esac
+    state=
+    while read -r k v _; do
+      [ "$k" = State: ] && state=$v && break
+    done 2>/dev/null < "$p/status"
+    case "$state" in ""|Z) ;; *) busy=1 ;; esac
+  done
+  last=$idle
+  if [ -n "$busy" ]; then idle=0; else idle=$((idle + %d)); fi
+  [ "$idle" = "$last" ] || echo "$idle"
+done`
+
+// keepAlive Returns the script for the timeout
+func keepAlive(timeout time.Duration) string {
+	seconds := int(timeout / time.Second)
+	interval := int(idleCheckInterval / time.Second)
+	if seconds < interval {
+		interval = seconds
+	}
+	if interval < 1 {
+		interval = 1
+	}
+	return fmt.Sprintf(keepAliveScript, seconds, interval, interval)
+}
+
+func determineIdleTimeout(cmd RepoCommand) (time.Duration, error) {
+	if cmd.IdleTimeout == "" {
+		return defaultIdleTimeout, nil
+	}
+	d, err := time.ParseDuration(cmd.IdleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid idleTimeout for %s: %v", cmd.Name, err)
+	}
+	return d, nil
+}
+
+// persistentContainerName There is one container per command and set of mounts
+func persistentContainerName(cmd RepoCommand, img string, volumes []string) string {
+	hash := sha256.Sum256([]byte(img + "\n" + strings.Join(volumes, "\n")))
+	return fmt.Sprintf("clic-%s-%x", containerSafeName(cmd.Name), hash[:4])
+}
+
+func containerSafeName(name string) string {
+	return strings.NewReplacer("@", "-", "/", "-", ":", "-").Replace(name)
+}
+
+// buildPersistentCommands Starts the long lived container if it isn't running
+// and execs the invocation in it
+func buildPersistentCommands(cmd RepoCommand, o dockerRunOptions, envFile []string) ([]Command, error) {
+	var cmds []Command
+	img, args, entrypoint := o.Image, o.Args, o.Entrypoint
+
+	timeout, err := determineIdleTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	name := persistentContainerName(cmd, img, append(append([]string{}, o.Volumes...), o.Mounts...))
+
+	// docker exec has no notion of entrypoint, so resolve the full command line
+	argv, err := resolveArgv(img, entrypoint, args)
+	if err != nil {
+		return nil, err
+	}
+
+	start := []string{"run", "-d", "--rm",
+		"--name", name,
+		"--label", LabelCommand + "=" + cmd.Name,
+		"--label", LabelMode + "=" + ModePersistent,
+		"--label", LabelIdleTimeout + "=" + timeout.String()}
+	if o.Init {
+		start = append(start, "--init")
+	}
+	start = append(start, o.Sandbox...)
+	start = append(start, o.Resources...)
+	for _, g := range o.Groups {
+		start = append(start, "--group-add", g)
+	}
+	for _, v := range o.Volumes {
+		start = append(start, "-v", v)
+	}
+	start = append(start, o.Mounts...)
+	start = append(start, "--entrypoint", "/bin/sh", img, "-c", keepAlive(timeout))
+
+	cmds = append(cmds, Command{
+		Name:      "docker",
+		Args:      start,
+		Skip:      containerRunning(name),
+		Quiet:     true,
+		Container: name,
+		Shared:    true,
+	})
+
+	ex := []string{"exec"}
+	if o.User > "" {
+		ex = append(ex, "--user", o.User)
+	}
+	if o.Stdin {
+		ex = append(ex, "-i")
+	}
+	if o.Tty {
+		ex = append(ex, "-t")
+	}
+	if o.Workdir > "" {
+		ex = append(ex, "-w", o.Workdir)
+	}
+	if o.EnvFile {
+		ex = append(ex, "--env-file", envFileDescriptor)
+	}
+	for _, k := range sortedKeys(o.Env) {
+		ex = append(ex, "-e", fmt.Sprintf("%s=%s", k, o.Env[k]))
+	}
+	for _, k := range o.PassEnv {
+		ex = append(ex, "-e", k)
+	}
+	ex = append(ex, name)
+	ex = append(ex, argv...)
+
+	cmds = append(cmds, Command{
+		Name:      "docker",
+		Args:      ex,
+		Exit:      true,
+		Stdin:     o.Stdin,
+		Tty:       o.Tty,
+		EnvFile:   envFile,
+		Container: name,
+	})
+
+	return cmds, nil
+}
+
+func containerRunning(name string) bool {
+	out, _ := exec.Command("docker", "ps", "-q", "--filter", "name=^/"+name+"$").Output()
+	return len(out) > 0
+}
+
+// persistentIdle Returns how long the container has been unused, and its timeout
+func persistentIdle(name string) (time.Duration, time.Duration, error) {
+	out, err := exec.Command("docker", "inspect", "--format",
+		fmt.Sprintf(`{{index .Config.Labels "%s"}}`, LabelIdleTimeout), name).Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Reading the logs doesn't run anything in the container, which would reset the idle time
+	out, err = exec.Command("docker", "logs", "--tail", "1", name).Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		seconds = 0
+	}
+
+	return time.Duration(seconds) * time.Second, timeout, nil
+}
+
+// startShared Starts the container, unless a concurrent invocation got to it
+// first, in which case it waits for that one to be running
+func startShared(c Command) error {
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+
+	err := startCommand(c)
+	if err == nil {
+		return nil
+	}
+	if !nameConflictPattern.Match(stderr.Bytes()) {
+		os.Stderr.Write(stderr.Bytes())
+		return err
+	}
+
+	deadline := time.Now().Add(sharedStartTimeout)
+	for !containerRunning(c.Container) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Container %s was started elsewhere but is not running", c.Container)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
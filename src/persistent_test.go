@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepAlive(t *testing.T) {
+	script := keepAlive(10 * time.Minute)
+	assertEqual(t, true, strings.Contains(script, `while [ "$idle" -lt 600 ]; do`))
+	assertEqual(t, true, strings.Contains(script, "sleep 5 & wait $!"))
+
+	// Short timeouts are checked more often
+	script = keepAlive(2 * time.Second)
+	assertEqual(t, true, strings.Contains(script, "sleep 2 & wait $!"))
+	assertEqual(t, true, strings.Contains(script, "idle=$((idle + 2))"))
+
+	out, err := exec.Command("/bin/sh", "-n", "-c", script).CombinedOutput()
+	assertEqual(t, nil, err)
+	assertEqual(t, "", string(out))
+}
+
+func TestStartSharedStartedElsewhere(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clic-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// docker ps finds the container the other invocation started
+	fake := filepath.Join(dir, "docker")
+	assertEqual(t, nil, ioutil.WriteFile(fake, []byte("#!/bin/sh\necho 4f2a\n"), 0755))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	conflict := `echo 'docker: Error response from daemon: Conflict. The container name "/clic-jq-1" is already in use by container "4f2a".' >&2; exit 125`
+	err = startShared(Command{Name: "sh", Args: []string{"-c", conflict}, Container: "clic-jq-1", Quiet: true})
+	assertEqual(t, nil, err)
+
+	// Other failures are reported
+	err = startShared(Command{Name: "sh", Args: []string{"-c", "echo 'no such image' >&2; exit 125"}, Container: "clic-jq-1", Quiet: true})
+	assertEqual(t, true, err != nil)
+}
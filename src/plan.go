@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// runPlan A command resolved ahead of time, so that the symlink doesn't need
+// to load and search the data and repo files on every invocation
+type runPlan struct {
+	// Sources Modification times of the files the plan was resolved
+	// from, the plan is stale if any of them changed
+	Sources map[string]int64
+	Command RepoCommand
+	// Grants The access the command was allowed on install
+	Grants []string
+}
+
+func planSourcePaths() ([]string, error) {
+	var paths []string
+	for _, f := range []func() (string, error){getDataPath, getRepoPath, getLocalRepoPath} {
+		p, err := f()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+func modTime(f string) int64 {
+	info, err := os.Stat(f)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// compilePlan Stores the resolved command under the name it is linked as
+func compilePlan(name string, cmd RepoCommand) error {
+	grants, err := loadGrants(cmd)
+	if err != nil {
+		return err
+	}
+
+	plan := runPlan{
+		Sources: make(map[string]int64),
+		Command: cmd,
+		Grants:  grants,
+	}
+
+	paths, err := planSourcePaths()
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		plan.Sources[p] = modTime(p)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+
+	f, err := getClicPlanPath(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(f), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, data, 0600)
+}
+
+// loadPlan Returns the precompiled plan, or nil when there is none or it is stale
+func loadPlan(name string) *runPlan {
+	f, err := getClicPlanPath(name)
+	if err != nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil
+	}
+
+	var plan runPlan
+	if err = json.Unmarshal(data, &plan); err != nil {
+		return nil
+	}
+
+	for p, t := range plan.Sources {
+		if modTime(p) != t {
+			return nil
+		}
+	}
+
+	return &plan
+}
+
+func removePlan(name string) error {
+	f, err := getClicPlanPath(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(f)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
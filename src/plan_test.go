@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPlanRecordsGrants(t *testing.T) {
+	defer withTempHome(t)()
+
+	clic, _ := getClicHome()
+	assertEqual(t, nil, os.MkdirAll(clic, 0700))
+
+	cmd := RepoCommand{Name: "dive@0.9", Image: "wagoodman/dive:v0.9", Docker: "socket"}
+	d, err := loadData()
+	assertEqual(t, nil, err)
+	assertEqual(t, nil, d.installCommand(cmd))
+
+	assertEqual(t, nil, compilePlan("dive", cmd))
+	plan := loadPlan("dive")
+	assertEqual(t, true, plan != nil)
+	assertEqual(t, true, requireConsent(plan.Command, plan.Grants) != nil)
+
+	assertEqual(t, nil, recordConsent(&d, cmd))
+	assertEqual(t, nil, compilePlan("dive", cmd))
+	plan = loadPlan("dive")
+	assertEqual(t, nil, requireConsent(plan.Command, plan.Grants))
+}
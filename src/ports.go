@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Exit code of docker run when the container could not be started
+const dockerRunFailed = 125
+
+// Ports are only published on the loopback interface
+const publishAddress = "127.0.0.1"
+
+const defaultPortURL = "http://localhost:{{.Port}}/"
+
+// PortOption A container port to publish on the host
+type PortOption struct {
+	Container int
+	// Host Preferred host port, another free port is used when
+	// it is not set or already taken
+	Host int `yaml:",omitempty"`
+	// URL Printed after start and opened by clic open, a template
+	// which can use {{.Port}}, the host port
+	URL string `yaml:"url,omitempty"`
+}
+
+// UnmarshalYAML Also accepts the short form [HOST:]CONTAINER
+func (p *PortOption) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		parsed, err := parsePortOption(s)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+
+	type plain PortOption
+	return unmarshal((*plain)(p))
+}
+
+func parsePortOption(s string) (PortOption, error) {
+	var p PortOption
+	var err error
+
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 1:
+		p.Container, err = strconv.Atoi(parts[0])
+	case 2:
+		p.Host, err = strconv.Atoi(parts[0])
+		if err == nil {
+			p.Container, err = strconv.Atoi(parts[1])
+		}
+	default:
+		err = fmt.Errorf("too many parts")
+	}
+	if err != nil {
+		return p, fmt.Errorf("Invalid port %s: %v", s, err)
+	}
+	return p, nil
+}
+
+// url Renders the URL the port can be reached at
+func (p PortOption) url(hostPort int) (string, error) {
+	u := p.URL
+	if u == "" {
+		u = defaultPortURL
+	}
+
+	t, err := template.New("url").Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	err = t.Execute(&b, struct{ Port int }{hostPort})
+	return b.String(), err
+}
+
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort(publishAddress, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// freePort Lets the system pick a port that is not in use
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(publishAddress, "0"))
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// publishedPort A port of the command and the host port picked for it
+type publishedPort struct {
+	Option PortOption
+	Host   int
+	URL    string
+}
+
+func publishPort(p PortOption, hostPort int) (publishedPort, error) {
+	u, err := p.url(hostPort)
+	return publishedPort{Option: p, Host: hostPort, URL: u}, err
+}
+
+// flag The value of the docker run -p flag
+func (p publishedPort) flag() string {
+	return fmt.Sprintf("%s:%d:%d", publishAddress, p.Host, p.Option.Container)
+}
+
+// determinePorts Picks the host ports. When the preferred port is taken, for
+// instance by another instance of the same command, a free port is used instead.
+func determinePorts(cmd RepoCommand) ([]publishedPort, error) {
+	var ports []publishedPort
+
+	for _, p := range cmd.Ports {
+		if p.Container <= 0 {
+			return nil, fmt.Errorf("Invalid container port for %s: %d", cmd.Name, p.Container)
+		}
+
+		hostPort := p.Host
+		if hostPort == 0 || !portFree(hostPort) {
+			var err error
+			hostPort, err = freePort()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		published, err := publishPort(p, hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid port url for %s: %v", cmd.Name, err)
+		}
+		ports = append(ports, published)
+	}
+
+	return ports, nil
+}
+
+func publishFlags(ports []publishedPort) []string {
+	var flags []string
+	for _, p := range ports {
+		flags = append(flags, "-p", p.flag())
+	}
+	return flags
+}
+
+// How often a command is started on other ports, when a picked port
+// was taken by someone else before docker could bind it
+const portAttempts = 3
+
+// Errors of docker when the host port is in use
+var portTakenPattern = regexp.MustCompile(`port is already allocated|address already in use`)
+
+// portConflict Passes the output on and notes when docker could not bind a port
+type portConflict struct {
+	out io.Writer
+
+	mu    sync.Mutex
+	found bool
+}
+
+func (w *portConflict) Write(p []byte) (int, error) {
+	if portTakenPattern.Match(p) {
+		w.mu.Lock()
+		w.found = true
+		w.mu.Unlock()
+	}
+	return w.out.Write(p)
+}
+
+func (w *portConflict) seen() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.found
+}
+
+// runPublished Starts the command, and again on other free ports when
+// docker found a picked port taken in the meantime
+func runPublished(c Command) error {
+	for attempt := 1; ; attempt++ {
+		conflict := &portConflict{out: os.Stderr}
+		c.Stderr = conflict
+
+		err := startCommand(c)
+		exitError, ok := err.(*exec.ExitError)
+		if !ok || exitError.ExitCode() != dockerRunFailed || !conflict.seen() || attempt == portAttempts {
+			return err
+		}
+
+		// A detached container was created before docker failed
+		removeContainer(c.Container)
+		c, err = republish(c)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// republish Moves the command to other free host ports
+func republish(c Command) (Command, error) {
+	args := append([]string(nil), c.Args...)
+	var ports []publishedPort
+
+	for _, old := range c.Ports {
+		hostPort, err := freePort()
+		if err != nil {
+			return c, err
+		}
+		p, err := publishPort(old.Option, hostPort)
+		if err != nil {
+			return c, err
+		}
+		ports = append(ports, p)
+
+		for i := 1; i < len(args); i++ {
+			if args[i-1] == "-p" && args[i] == old.flag() {
+				args[i] = p.flag()
+			}
+		}
+	}
+
+	c.Args = args
+	c.Ports = ports
+	return c, nil
+}
+
+// publishedPorts Returns the host port for each published container port
+// of a running container
+func publishedPorts(container string) (map[int]int, error) {
+	out, err := exec.Command("docker", "port", container).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// 8888/tcp -> 127.0.0.1:49153
+	ports := make(map[int]int)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, " -> ")
+		if len(parts) != 2 {
+			continue
+		}
+		c, err := strconv.Atoi(strings.Split(parts[0], "/")[0])
+		if err != nil {
+			continue
+		}
+		_, h, err := net.SplitHostPort(parts[1])
+		if err != nil {
+			continue
+		}
+		if port, err := strconv.Atoi(h); err == nil {
+			ports[c] = port
+		}
+	}
+	return ports, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDeterminePorts(t *testing.T) {
+	var cmd RepoCommand
+	err := yaml.Unmarshal([]byte("ports:\n- 8888\n- 9000:90\n- container: 8080\n  url: http://localhost:{{.Port}}/ui\n"), &cmd)
+	assertEqual(t, nil, err)
+	assertEqual(t, 8888, cmd.Ports[0].Container)
+
+	// A taken preferred port falls back to a free one
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	taken := l.Addr().(*net.TCPAddr).Port
+	cmd.Ports[0].Host = taken
+
+	ports, err := determinePorts(cmd)
+	assertEqual(t, nil, err)
+	flags := publishFlags(ports)
+	assertEqual(t, 6, len(flags))
+	assertEqual(t, true, strings.HasSuffix(flags[3], ":90"))
+	assertEqual(t, "-p", flags[0])
+	assertEqual(t, false, strings.HasPrefix(flags[1], "127.0.0.1:"+strconv.Itoa(taken)+":"))
+	assertEqual(t, true, strings.HasSuffix(flags[5], ":8080"))
+
+	hostPort := strings.Split(flags[5], ":")[1]
+	assertEqual(t, "http://localhost:"+hostPort+"/ui", ports[2].URL)
+}
+
+func TestRunPublishedRetriesTakenPort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clic-ports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	attempts := filepath.Join(dir, "attempts")
+
+	port, err := publishPort(PortOption{Container: 8080}, 1)
+	assertEqual(t, nil, err)
+
+	// Fails like docker run does when the port was taken after it was picked
+	script := `echo "$1" >> ` + attempts + `; echo "Bind for $1 failed: port is already allocated" >&2; exit 125`
+	var stderr bytes.Buffer
+	err = runPublished(Command{
+		Name:   "sh",
+		Args:   []string{"-c", script, "-p", port.flag()},
+		Ports:  []publishedPort{port},
+		Stderr: &stderr,
+	})
+	exitError, ok := err.(*exec.ExitError)
+	assertEqual(t, true, ok)
+	assertEqual(t, dockerRunFailed, exitError.ExitCode())
+
+	data, _ := ioutil.ReadFile(attempts)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assertEqual(t, portAttempts, len(lines))
+	assertEqual(t, "127.0.0.1:1:8080", lines[0])
+	assertEqual(t, false, lines[1] == lines[0])
+	assertEqual(t, true, strings.HasSuffix(lines[1], ":8080"))
+}
+
+func TestPortsWithoutNetwork(t *testing.T) {
+	defer withTempHome(t)()
+
+	_, err := BuildCommands(RepoCommand{
+		Name:    "notebook@1",
+		Image:   "jupyter/base-notebook",
+		Sandbox: SandboxOffline,
+		Ports:   []PortOption{{Container: 8888}},
+	}, nil, BuildOptions{})
+	assertEqual(t, "Ports can not be published for notebook@1, its sandbox has no network", err.Error())
+}
@@ -17,6 +17,10 @@ const (
 
 	// MountPwd Mount the pwd only
 	MountPwd MountOption = "pwd"
+
+	// MountProject Mount the root of the project the pwd is in,
+	// found by walking up to a folder with a marker such as .git
+	MountProject MountOption = "project"
 )
 
 // StdInOption How to handle stdin
@@ -26,10 +30,37 @@ const (
 	// StdInEmpty Default handling for stdin, when not specified in the repo yaml
 	StdInEmpty StdInOption = ""
 
-	// StdInFalse Disable stdin for the spawned process
+	// StdInAuto Attach stdin when it is a terminal, pipe or file
+	StdInAuto StdInOption = "auto"
+
+	// StdInAlways Always attach stdin
+	StdInAlways StdInOption = "always"
+
+	// StdInNever Disable stdin for the spawned process
+	StdInNever StdInOption = "never"
+
+	// StdInFile Attach stdin only when it is a pipe or file, never the terminal
+	StdInFile StdInOption = "file"
+
+	// StdInFalse Disable stdin for the spawned process, same as never
 	StdInFalse StdInOption = "false"
 )
 
+// TtyOption Whether to allocate a tty in the container
+type TtyOption string
+
+const (
+	// TtyAuto Allocate a tty when both stdin and stdout are a terminal.
+	// This is the default when not specified in the repo yaml.
+	TtyAuto TtyOption = "auto"
+
+	// TtyAlways Always allocate a tty
+	TtyAlways TtyOption = "always"
+
+	// TtyNever Never allocate a tty
+	TtyNever TtyOption = "never"
+)
+
 // RepoCommand is an entry in the repo file
 type RepoCommand struct {
 	Name       string
@@ -39,10 +70,89 @@ type RepoCommand struct {
 	Entrypoint string
 	Volumes    []string
 
+	// Base and Packages Generate the image from a list of packages
+	// installed on top of a base image. Without packages, the base
+	// image is run as is.
+	Base     string   `yaml:",omitempty"`
+	Packages []string `yaml:",omitempty"`
+	// PackageManager apk or apt, when it can't be told from the base image
+	PackageManager string `yaml:"packageManager,omitempty"`
+
+	// Script Shell script to run in the image instead of its entrypoint.
+	// It is passed as an argument so it never touches the host disk.
+	Script string `yaml:",omitempty"`
+
+	// Binary Run a native executable instead of a container
+	Binary *BinaryOption `yaml:",omitempty"`
+
+	// Persistent Keep one container running and exec into it, until
+	// it has been idle for IdleTimeout
+	Persistent  bool   `yaml:",omitempty"`
+	IdleTimeout string `yaml:"idleTimeout,omitempty"`
+
+	// Env Environment variables to pass to the container
+	Env []string `yaml:",omitempty"`
+
+	// User to run as in the container
+	User string `yaml:",omitempty"`
+
+	// SSH Forward the ssh agent, and mount known_hosts read-only
+	SSH        SSHOption `yaml:"ssh,omitempty"`
+	KnownHosts bool      `yaml:"knownHosts,omitempty"`
+
+	// Docker Access to the host docker daemon, which must
+	// be allowed by the user on install
+	Docker DockerOption `yaml:",omitempty"`
+
+	// PathArgs Mount and rewrite host paths given as arguments
+	PathArgs PathArgsOption `yaml:"pathArgs,omitempty"`
+
+	// Sandbox Restrictions for the container: default, restricted or offline
+	Sandbox SandboxOption `yaml:",omitempty"`
+
+	// Resources Memory, cpu and process limits for the container
+	Resources ResourcesOption `yaml:",omitempty"`
+
+	// Timeout After which the container is stopped, e.g. 10m
+	Timeout string `yaml:",omitempty"`
+
+	// Ports Container ports to publish on the host
+	Ports []PortOption `yaml:",omitempty"`
+
+	// Services Containers started on a private network before
+	// the command, and removed after it
+	Services []ServiceOption `yaml:",omitempty"`
+
+	// Caches Container folders kept in volumes between runs
+	Caches []CacheOption `yaml:",omitempty"`
+
+	// MapOutput Rewrite container paths in the output to host paths
+	MapOutput bool `yaml:"mapOutput,omitempty"`
+
+	// Configs Host config files and folders to mount, whatever the mount mode
+	Configs []ConfigMount `yaml:",omitempty"`
+
+	// Secrets Values given to the container as files or
+	// environment without showing in docker inspect
+	Secrets []SecretOption `yaml:",omitempty"`
+
+	// Init Run an init process in the container to reap zombies
+	Init bool `yaml:",omitempty"`
+
 	// Options
 	Fixttydims bool
 	Mount      MountOption
 	Stdin      StdInOption
+	Tty        TtyOption `yaml:",omitempty"`
+
+	// Markers Files or folders that mark the project root, default .git.
+	// Roots Additional folders to mount, relative to the project root.
+	// When the project is inside one, that one is the project root.
+	Markers []string `yaml:",omitempty"`
+	Roots   []string `yaml:",omitempty"`
+
+	// Labels Fill in unset options from the io.clic.* labels of the image
+	Labels bool `yaml:",omitempty"`
 }
 
 // Repo is the repository of all known commands
@@ -73,11 +183,28 @@ func loadRepo() (Repo, error) {
 		repo.Commands[k] = v
 	}
 
+	// Local definitions take precedence
+	local, err := loadLocalRepo()
+	if err != nil {
+		return repo, err
+	}
+	if repo.Commands == nil {
+		repo.Commands = make(map[string]RepoCommand)
+	}
+	for k, v := range local.Commands {
+		repo.Commands[k] = v
+	}
+
 	return repo, nil
 }
 
 func (r Repo) resolve(cmd CommandVersion) *RepoCommand {
 	if cmd.hasVersion == false {
+		// A command saved under the plain name, like a local definition,
+		// wins over the versions of the same name
+		if v, ok := r.Commands[cmd.command]; ok {
+			return &v
+		}
 		return r.resolveLatest(cmd)
 	}
 
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalCommandTakesPrecedence(t *testing.T) {
+	defer withTempHome(t)()
+
+	repoPath, _ := getRepoPath()
+	assertEqual(t, nil, os.MkdirAll(filepath.Dir(repoPath), 0700))
+	assertEqual(t, nil, ioutil.WriteFile(repoPath, []byte(`commands:
+  jq@1.6:
+    image: stedolan/jq:1.6
+  yq@2.4:
+    image: mikefarah/yq:2.4
+`), 0600))
+	assertEqual(t, nil, saveLocalCommand(RepoCommand{Name: "jq", Image: "local/jq"}))
+
+	repo, err := loadRepo()
+	assertEqual(t, nil, err)
+	assertEqual(t, "local/jq", repo.resolve(parseCommand("jq")).Image)
+	assertEqual(t, "stedolan/jq:1.6", repo.resolve(parseCommand("jq@1.6")).Image)
+	assertEqual(t, "mikefarah/yq:2.4", repo.resolve(parseCommand("yq")).Image)
+
+	d := Data{Commands: map[string]RepoCommand{
+		"jq@1.6": {Name: "jq@1.6", Image: "stedolan/jq:1.6"},
+		"jq":     {Name: "jq", Image: "local/jq"},
+	}}
+	assertEqual(t, "local/jq", d.resolve(parseCommand("jq")).Image)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Exit code of a command that ran into its timeout, as with timeout(1)
+const timeoutExitCode = 124
+
+// ResourcesOption Limits for the container
+type ResourcesOption struct {
+	// Memory e.g. 512m or 2g
+	Memory string `yaml:",omitempty"`
+	// CPUs e.g. 1.5
+	CPUs string `yaml:"cpus,omitempty"`
+	// Pids Maximum number of processes
+	Pids int `yaml:",omitempty"`
+}
+
+// timeoutError The command was stopped because it ran too long
+type timeoutError struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s stopped after timeout of %v", e.Name, e.Timeout)
+}
+
+// determineResources Returns the docker run flags for the resource limits
+func determineResources(cmd RepoCommand) []string {
+	var flags []string
+	if cmd.Resources.Memory > "" {
+		flags = append(flags, "--memory", cmd.Resources.Memory)
+	}
+	if cmd.Resources.CPUs > "" {
+		flags = append(flags, "--cpus", cmd.Resources.CPUs)
+	}
+	if cmd.Resources.Pids > 0 {
+		flags = append(flags, "--pids-limit", strconv.Itoa(cmd.Resources.Pids))
+	}
+	return flags
+}
+
+func determineTimeout(cmd RepoCommand) (time.Duration, error) {
+	if cmd.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(cmd.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid timeout for %s: %v", cmd.Name, err)
+	}
+	return d, nil
+}
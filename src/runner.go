@@ -1,40 +1,149 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
-func run(cmds []Command) {
+func run(cmds []Command) error {
+	// Only worth a call to docker when a container is started
 	for _, c := range cmds {
-		runCommand(c)
+		if c.Container > "" {
+			cleanupOrphans()
+			break
+		}
+	}
+
+	var cleanup []Command
+	for _, c := range cmds {
+		cleanup = append(cleanup, c.Cleanup...)
+	}
+
+	// An interrupt ends the run after the current step,
+	// rather than clic, so the cleanup still happens
+	var interrupted chan os.Signal
+	if len(cleanup) > 0 {
+		defer runCleanup(cleanup)
+		interrupted = make(chan os.Signal, 1)
+		signal.Notify(interrupted, forwardedSignals...)
+		defer signal.Stop(interrupted)
+	}
+
+	for _, c := range cmds {
+		var err error
+		if c.Retry > 0 && !c.Skip {
+			err = retryCommand(c, interrupted)
+		} else {
+			err = runCommand(c)
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case sig := <-interrupted:
+			return fmt.Errorf("Interrupted by %v", sig)
+		default:
+		}
 	}
+	return nil
 }
 
-func runCommand(c Command) {
+func runCommand(c Command) error {
 	if c.Skip {
-		return
+		return nil
+	}
+	if len(c.Ports) > 0 {
+		return runPublished(c)
+	}
+	if c.Shared {
+		return startShared(c)
+	}
+	return startCommand(c)
+}
+
+func startCommand(c Command) error {
+	for _, p := range c.Ports {
+		fmt.Fprintln(os.Stderr, "✓ Listening on", p.URL)
+	}
+
+	if c.Exit && c.Supervise {
+		defer removeSecrets(c.Secrets)
+		if err := writeSecrets(c.Secrets); err != nil {
+			return err
+		}
+		if c.AgentProxy != nil {
+			stop, err := c.AgentProxy.start()
+			if err != nil {
+				return err
+			}
+			defer stop()
+		}
+		return superviseCommand(c)
+	}
+
+	if canExec && c.Exit && c.StdinFile == "" && c.StdinData == "" && !c.Quiet && len(c.EnvFile) == 0 {
+		return execCommand(c)
 	}
 
 	p := exec.Command(c.Name, c.Args...)
 	p.Stdout = os.Stdout
-	p.Stderr = os.Stderr
+	p.Stderr = c.stderr()
+
+	if c.Quiet {
+		p.Stdout = nil
+	}
 
 	if c.Stdin {
 		p.Stdin = os.Stdin
 	}
 
 	if c.StdinFile > "" {
-		p.Stdin, _ = os.Open(c.StdinFile)
+		f, err := os.Open(c.StdinFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		p.Stdin = f
 	}
 
-	err := p.Run()
+	if c.StdinData > "" {
+		p.Stdin = strings.NewReader(c.StdinData)
+	}
+
+	closeEnvFile, err := attachEnvFile(p, c.EnvFile)
+	if err != nil {
+		return err
+	}
+	defer closeEnvFile()
 
-	if c.Exit || err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitError.ExitCode())
-			return
+	err = p.Run()
+	if err == nil && c.Detached != nil {
+		c.Detached.Started = time.Now()
+		if err := recordDetached(*c.Detached); err != nil {
+			return err
 		}
+		fmt.Println("✓ Started in the background:", c.Container)
+	}
+	if err == nil && c.Exit {
 		os.Exit(0)
 	}
+	return err
+}
+
+// execCommand Replaces the clic process with the command, so that signals,
+// job control and the exit code belong to the command itself.
+// Only returns if the command could not be started.
+func execCommand(c Command) error {
+	path, err := exec.LookPath(c.Name)
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(path, append([]string{c.Name}, c.Args...), os.Environ())
 }
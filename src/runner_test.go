@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunChecksOrphansOnlyForContainers(t *testing.T) {
+	defer withTempHome(t)()
+
+	clic, _ := getClicHome()
+	assertEqual(t, nil, os.MkdirAll(clic, 0700))
+	marker := filepath.Join(clic, "orphans-checked")
+
+	// Such as a native binary
+	assertEqual(t, nil, run([]Command{{Name: "true"}}))
+	_, err := os.Stat(marker)
+	assertEqual(t, true, os.IsNotExist(err))
+
+	assertEqual(t, nil, run([]Command{{Name: "true", Container: "clic-tool-1"}}))
+	_, err = os.Stat(marker)
+	assertEqual(t, nil, err)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SandboxOption Named set of restrictions for the container
+type SandboxOption string
+
+const (
+	// SandboxDefault Default docker privileges and network
+	SandboxDefault SandboxOption = "default"
+	// SandboxRestricted No capabilities, no privilege escalation, limited
+	// processes and a read-only root filesystem with a writable /tmp
+	SandboxRestricted SandboxOption = "restricted"
+	// SandboxOffline Restricted and without network
+	SandboxOffline SandboxOption = "offline"
+)
+
+// Maximum number of processes in a restricted container
+const sandboxPidsLimit = "256"
+
+// How strict each profile is
+var sandboxLevels = map[SandboxOption]int{
+	"":                0,
+	SandboxDefault:    0,
+	SandboxRestricted: 1,
+	SandboxOffline:    2,
+}
+
+// Policy User settings that apply to all commands
+type Policy struct {
+	// Sandbox The minimum sandbox profile, commands asking for less get this one
+	Sandbox SandboxOption `yaml:",omitempty"`
+}
+
+func loadPolicy() (Policy, error) {
+	var p Policy
+
+	f, err := getPolicyPath()
+	if err != nil {
+		return p, err
+	}
+
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return p, err
+	}
+
+	err = yaml.Unmarshal(data, &p)
+	if err != nil {
+		return p, err
+	}
+
+	if _, ok := sandboxLevels[p.Sandbox]; !ok {
+		return p, fmt.Errorf("Unknown sandbox profile in %s: %s", f, p.Sandbox)
+	}
+	return p, nil
+}
+
+// determineSandbox Returns the docker run flags for the command's sandbox
+// profile, or the policy's minimum when that is stricter
+func determineSandbox(cmd RepoCommand) ([]string, error) {
+	if _, ok := sandboxLevels[cmd.Sandbox]; !ok {
+		return nil, fmt.Errorf("Unknown sandbox profile for %s: %s", cmd.Name, cmd.Sandbox)
+	}
+
+	policy, err := loadPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	sandbox := cmd.Sandbox
+	if sandboxLevels[policy.Sandbox] > sandboxLevels[sandbox] {
+		sandbox = policy.Sandbox
+	}
+
+	var flags []string
+	if sandboxLevels[sandbox] >= sandboxLevels[SandboxRestricted] {
+		flags = append(flags,
+			"--cap-drop", "ALL",
+			"--security-opt", "no-new-privileges")
+		// An explicit limit from the resources takes precedence
+		if cmd.Resources.Pids == 0 {
+			flags = append(flags, "--pids-limit", sandboxPidsLimit)
+		}
+		flags = append(flags,
+			"--read-only",
+			"--tmpfs", "/tmp")
+	}
+	if sandbox == SandboxOffline {
+		flags = append(flags, "--network", "none")
+	}
+	return flags, nil
+}
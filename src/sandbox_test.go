@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetermineSandbox(t *testing.T) {
+	defer withTempHome(t)()
+
+	flags, err := determineSandbox(RepoCommand{Name: "jq"})
+	assertEqual(t, nil, err)
+	assertEqual(t, 0, len(flags))
+
+	flags, _ = determineSandbox(RepoCommand{Name: "jq", Sandbox: SandboxOffline})
+	assertEqual(t, "--cap-drop ALL --security-opt no-new-privileges --pids-limit 256 --read-only --tmpfs /tmp --network none", strings.Join(flags, " "))
+
+	_, err = determineSandbox(RepoCommand{Name: "jq", Sandbox: "strict"})
+	assertEqual(t, "Unknown sandbox profile for jq: strict", err.Error())
+
+	// The policy raises the minimum but never lowers a command's profile
+	clic, _ := getClicHome()
+	os.MkdirAll(clic, 0700)
+	ioutil.WriteFile(filepath.Join(clic, "policy.yaml"), []byte("sandbox: restricted\n"), 0600)
+
+	flags, _ = determineSandbox(RepoCommand{Name: "jq"})
+	assertEqual(t, "--read-only", flags[6])
+	assertEqual(t, 9, len(flags))
+
+	flags, _ = determineSandbox(RepoCommand{Name: "jq", Sandbox: SandboxOffline})
+	assertEqual(t, "none", flags[len(flags)-1])
+}
+
+func TestDetermineResources(t *testing.T) {
+	defer withTempHome(t)()
+
+	cmd := RepoCommand{Name: "terraform", Sandbox: SandboxRestricted,
+		Resources: ResourcesOption{Memory: "1g", CPUs: "1.5", Pids: 100}}
+	assertEqual(t, "--memory 1g --cpus 1.5 --pids-limit 100", strings.Join(determineResources(cmd), " "))
+
+	// The sandbox leaves the process limit to the resources
+	flags, _ := determineSandbox(cmd)
+	assertEqual(t, "--cap-drop ALL --security-opt no-new-privileges --read-only --tmpfs /tmp", strings.Join(flags, " "))
+}
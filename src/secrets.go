@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SecretDelivery How a secret is handed to the container
+type SecretDelivery string
+
+const (
+	// SecretAsFile Mount the secret as a read-only file, the default
+	SecretAsFile SecretDelivery = "file"
+
+	// SecretAsEnv Export the secret to the environment of the container process only
+	SecretAsEnv SecretDelivery = "env"
+)
+
+const secretsContainerDir = "/run/secrets"
+
+// SecretOption A secret given to the container without it appearing on the
+// command line or in docker inspect. The value comes from one of Env, File or
+// Command, by default the host variable of the same name.
+type SecretOption struct {
+	Name string
+
+	// Env Host environment variable
+	Env string `yaml:",omitempty"`
+
+	// File and Key A .env style file, and the key in it which defaults to Name
+	File string `yaml:",omitempty"`
+	Key  string `yaml:",omitempty"`
+
+	// Command Credential helper that prints the value
+	Command []string `yaml:",omitempty"`
+
+	As SecretDelivery `yaml:",omitempty"`
+
+	// Path in the container when delivered as a file
+	Path string `yaml:",omitempty"`
+}
+
+// secretFile A secret and the host file it is written to for the length of a run
+type secretFile struct {
+	HostPath string
+	Secret   SecretOption
+}
+
+func (s SecretOption) containerPath() string {
+	if s.Path > "" {
+		return s.Path
+	}
+	return path.Join(secretsContainerDir, s.Name)
+}
+
+// resolve Reads the value of the secret from its source
+func (s SecretOption) resolve() (string, error) {
+	switch {
+	case len(s.Command) > 0:
+		out, err := exec.Command(s.Command[0], s.Command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("Credential helper for secret %s failed: %v", s.Name, err)
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+
+	case s.File > "":
+		key := s.Key
+		if key == "" {
+			key = s.Name
+		}
+		return readEnvFile(expandHome(s.File), key)
+
+	default:
+		name := s.Env
+		if name == "" {
+			name = s.Name
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("Secret %s: %s is not set", s.Name, name)
+		}
+		return v, nil
+	}
+}
+
+// readEnvFile Returns the value of the key in a file of KEY=value lines
+func readEnvFile(f string, key string) (string, error) {
+	in, err := os.Open(f)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != key {
+			continue
+		}
+
+		v := strings.TrimSpace(parts[1])
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		return v, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s not found in %s", key, f)
+}
+
+// Valid secret names, which are used as file and variable names
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Memory backed storage the secrets are written to, so they never reach the disk
+var secretsTmpfs = "/dev/shm"
+
+// getSecretsDir Returns where the secrets of a container are kept during the
+// run. Without memory backed storage, secrets are refused rather than written
+// to disk.
+func getSecretsDir(container string) (string, error) {
+	if info, err := os.Stat(secretsTmpfs); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("Secrets need memory backed storage at %s, which is not available on this host", secretsTmpfs)
+	}
+	return filepath.Join(secretsTmpfs, fmt.Sprintf("clic-%d", os.Getuid()), container), nil
+}
+
+// determineSecrets Returns the host files and mounts for the secrets, and
+// the script which exports the env secrets before running the real command
+func determineSecrets(cmd RepoCommand, container string) ([]secretFile, []string, string, error) {
+	var files []secretFile
+	var volumes []string
+	var exports []string
+
+	if len(cmd.Secrets) == 0 {
+		return nil, nil, "", nil
+	}
+
+	dir, err := getSecretsDir(container)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	for _, s := range cmd.Secrets {
+		if s.Name == "" {
+			return nil, nil, "", fmt.Errorf("Secret without a name in %s", cmd.Name)
+		}
+		// The name is a file name and a shell variable
+		if !secretNamePattern.MatchString(s.Name) {
+			return nil, nil, "", fmt.Errorf("Invalid secret name in %s: %s", cmd.Name, s.Name)
+		}
+		if s.As != "" && s.As != SecretAsFile && s.As != SecretAsEnv {
+			return nil, nil, "", fmt.Errorf("Unknown delivery for secret %s: %s", s.Name, s.As)
+		}
+
+		hostPath := filepath.Join(dir, s.Name)
+		files = append(files, secretFile{HostPath: hostPath, Secret: s})
+		volumes = append(volumes, hostPath+":"+s.containerPath()+":ro")
+
+		if s.As == SecretAsEnv {
+			exports = append(exports, fmt.Sprintf(`export %s="$(cat %s)"; `, s.Name, shellQuote(s.containerPath())))
+		}
+	}
+
+	script := ""
+	if len(exports) > 0 {
+		script = strings.Join(exports, "") + `exec "$@"`
+	}
+
+	return files, volumes, script, nil
+}
+
+// writeSecrets Resolves the secrets and writes them for the length of the run
+func writeSecrets(files []secretFile) error {
+	for _, f := range files {
+		v, err := f.Secret.resolve()
+		if err != nil {
+			return err
+		}
+
+		// The folder keeps other users out, the file has to be readable
+		// by whatever user the container runs as
+		err = os.MkdirAll(filepath.Dir(f.HostPath), 0700)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(f.HostPath, []byte(v), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeSecrets(files []secretFile) {
+	for _, f := range files {
+		os.Remove(f.HostPath)
+		os.Remove(filepath.Dir(f.HostPath))
+	}
+}
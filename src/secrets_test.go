@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "clic-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString("# comment\nexport TOKEN=\"abc=123\"\nOTHER=x\n")
+	f.Close()
+
+	v, err := readEnvFile(f.Name(), "TOKEN")
+	assertEqual(t, nil, err)
+	assertEqual(t, "abc=123", v)
+
+	_, err = readEnvFile(f.Name(), "MISSING")
+	assertEqual(t, true, err != nil)
+}
+
+// withSecretsTmpfs Points the secrets at a temporary folder, also on hosts without /dev/shm
+func withSecretsTmpfs(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "clic-shm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := secretsTmpfs
+	secretsTmpfs = dir
+	return func() {
+		secretsTmpfs = old
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDetermineSecrets(t *testing.T) {
+	defer withSecretsTmpfs(t)()
+
+	cmd := RepoCommand{Name: "tool", Secrets: []SecretOption{
+		{Name: "A"},
+		{Name: "B", As: SecretAsEnv},
+	}}
+
+	files, volumes, script, err := determineSecrets(cmd, "clic-tool-1")
+	assertEqual(t, nil, err)
+	assertEqual(t, 2, len(files))
+	assertEqual(t, files[0].HostPath+":/run/secrets/A:ro", volumes[0])
+	assertEqual(t, `export B="$(cat /run/secrets/B)"; exec "$@"`, script)
+}
+
+func TestDetermineSecretsInvalidName(t *testing.T) {
+	defer withSecretsTmpfs(t)()
+
+	for _, name := range []string{"../../.bashrc", "A;rm -rf /", "1A", "A-B"} {
+		cmd := RepoCommand{Name: "tool", Secrets: []SecretOption{{Name: name, As: SecretAsEnv}}}
+		_, _, _, err := determineSecrets(cmd, "clic-tool-1")
+		assertEqual(t, "Invalid secret name in tool: "+name, err.Error())
+	}
+
+	// The container path is quoted in the script
+	cmd := RepoCommand{Name: "tool", Secrets: []SecretOption{{Name: "A", As: SecretAsEnv, Path: "/run/my secrets/a"}}}
+	_, _, script, err := determineSecrets(cmd, "clic-tool-1")
+	assertEqual(t, nil, err)
+	assertEqual(t, `export A="$(cat '/run/my secrets/a')"; exec "$@"`, script)
+}
+
+func TestDetermineSecretsWithoutTmpfs(t *testing.T) {
+	old := secretsTmpfs
+	defer func() { secretsTmpfs = old }()
+	secretsTmpfs = "/nonexistent/shm"
+
+	cmd := RepoCommand{Name: "tool", Secrets: []SecretOption{{Name: "A"}}}
+	_, _, _, err := determineSecrets(cmd, "clic-tool-1")
+	assertEqual(t, "Secrets need memory backed storage at /nonexistent/shm, which is not available on this host", err.Error())
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// How long a service may take to become ready by default
+const defaultReadyTimeout = time.Minute
+
+// ServiceOption A container the command needs while it runs, such as a database.
+// It is reachable from the command by its name.
+type ServiceOption struct {
+	Name  string
+	Image string
+	// Env Fixed values as NAME=value
+	Env  []string `yaml:",omitempty"`
+	Args []string `yaml:",omitempty"`
+	// Ready Shell command run in the service container, which
+	// succeeds once the service accepts connections
+	Ready        string `yaml:",omitempty"`
+	ReadyTimeout string `yaml:"readyTimeout,omitempty"`
+}
+
+// determineServices Returns the commands that create the private network and
+// start the services on it, and the ones that tear everything down again.
+// An offline sandbox gets an internal network, so the services are reachable
+// but nothing else is.
+func determineServices(cmd RepoCommand, network string, labels map[string]string, internal bool) ([]Command, []Command, error) {
+	var start []Command
+	var teardown []Command
+
+	create := []string{"network", "create"}
+	if internal {
+		create = append(create, "--internal")
+	}
+	for _, k := range sortedKeys(labels) {
+		create = append(create, "--label", k+"="+labels[k])
+	}
+	create = append(create, network)
+	start = append(start, Command{Name: "docker", Args: create, Quiet: true})
+
+	var names []string
+	for _, s := range cmd.Services {
+		if s.Name == "" || s.Image == "" {
+			return nil, nil, fmt.Errorf("Service of %s needs a name and an image", cmd.Name)
+		}
+		if contains(names, s.Name) {
+			return nil, nil, fmt.Errorf("Duplicate service %s for %s", s.Name, cmd.Name)
+		}
+		names = append(names, s.Name)
+
+		name := network + "-" + containerSafeName(s.Name)
+		run := []string{"run", "-d", "--rm", "--name", name,
+			"--network", network, "--network-alias", s.Name}
+		for _, k := range sortedKeys(labels) {
+			run = append(run, "--label", k+"="+labels[k])
+		}
+		for _, e := range s.Env {
+			if !strings.Contains(e, "=") {
+				return nil, nil, fmt.Errorf("Invalid env for service %s of %s: %s", s.Name, cmd.Name, e)
+			}
+			run = append(run, "-e", e)
+		}
+		run = append(run, s.Image)
+		run = append(run, s.Args...)
+		start = append(start, Command{Name: "docker", Args: run, Quiet: true})
+		teardown = append(teardown, Command{Name: "docker", Args: []string{"rm", "-f", name}, Quiet: true})
+	}
+
+	// Wait for all of them only after all are started
+	for _, s := range cmd.Services {
+		if s.Ready == "" {
+			continue
+		}
+		timeout := defaultReadyTimeout
+		if s.ReadyTimeout > "" {
+			d, err := time.ParseDuration(s.ReadyTimeout)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid readyTimeout for service %s of %s: %v", s.Name, cmd.Name, err)
+			}
+			timeout = d
+		}
+		name := network + "-" + containerSafeName(s.Name)
+		start = append(start, Command{
+			Name:  "docker",
+			Args:  []string{"exec", name, "/bin/sh", "-c", s.Ready},
+			Quiet: true,
+			Retry: timeout,
+		})
+	}
+
+	teardown = append(teardown, Command{Name: "docker", Args: []string{"network", "rm", network}, Quiet: true})
+	return start, teardown, nil
+}
+
+// retryCommand Runs the command until it succeeds, the time is up or
+// it is interrupted
+func retryCommand(c Command, interrupted <-chan os.Signal) error {
+	deadline := time.Now().Add(c.Retry)
+	for {
+		p := exec.Command(c.Name, c.Args...)
+		err := p.Run()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Not ready after %v: %s %s", c.Retry, c.Name, strings.Join(c.Args, " "))
+		}
+		select {
+		case sig := <-interrupted:
+			return fmt.Errorf("Interrupted by %v", sig)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// runCleanup Runs the commands regardless of their errors
+func runCleanup(cmds []Command) {
+	for _, c := range cmds {
+		exec.Command(c.Name, c.Args...).Run()
+	}
+}
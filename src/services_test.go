@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDetermineServices(t *testing.T) {
+	var cmd RepoCommand
+	err := yaml.Unmarshal([]byte(`
+name: migrate
+services:
+- name: db
+  image: postgres:12
+  env: [POSTGRES_PASSWORD=test]
+  ready: pg_isready -U postgres
+`), &cmd)
+	assertEqual(t, nil, err)
+
+	labels := map[string]string{LabelPid: "42"}
+	start, teardown, err := determineServices(cmd, "clic-migrate-42", labels, true)
+	assertEqual(t, nil, err)
+
+	assertEqual(t, 3, len(start))
+	assertEqual(t, "network create --internal --label clic.pid=42 clic-migrate-42", strings.Join(start[0].Args, " "))
+	assertEqual(t, "run -d --rm --name clic-migrate-42-db --network clic-migrate-42 --network-alias db --label clic.pid=42 -e POSTGRES_PASSWORD=test postgres:12", strings.Join(start[1].Args, " "))
+	assertEqual(t, "exec clic-migrate-42-db /bin/sh -c pg_isready -U postgres", strings.Join(start[2].Args, " "))
+	assertEqual(t, defaultReadyTimeout, start[2].Retry)
+
+	assertEqual(t, 2, len(teardown))
+	assertEqual(t, "rm -f clic-migrate-42-db", strings.Join(teardown[0].Args, " "))
+	assertEqual(t, "network rm clic-migrate-42", strings.Join(teardown[1].Args, " "))
+
+	sandbox := []string{"--read-only", "--network", "none", "--tmpfs", "/tmp"}
+	assertEqual(t, true, removeFlag(&sandbox, "--network", "none"))
+	assertEqual(t, "--read-only --tmpfs /tmp", strings.Join(sandbox, " "))
+}
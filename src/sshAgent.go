@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// SSHOption What SSH access the container gets
+type SSHOption string
+
+const (
+	// SSHAgent Forward the host's ssh agent
+	SSHAgent SSHOption = "agent"
+
+	agentContainerSocket = "/run/clic/ssh-agent.sock"
+
+	// Docker Desktop for Mac forwards the agent into its VM at this path
+	dockerDesktopAgentSocket = "/run/host-services/ssh-auth.sock"
+)
+
+// agentProxy Relays the host agent through a socket that any user in the
+// container can connect to
+type agentProxy struct {
+	Listen string
+	Target string
+}
+
+// isRootUser Whether the container user, as given to docker run --user, is
+// known to be root. Without a user it is whatever the image says, which
+// can't be known without inspecting it, so it is not assumed to be root.
+func isRootUser(user string) bool {
+	name := strings.SplitN(user, ":", 2)[0]
+	return name == "root" || name == "0"
+}
+
+// determineSSH Returns the mounts and environment for SSH access, and the
+// proxy needed when the container user can't access the agent socket
+func determineSSH(cmd RepoCommand, container string) ([]string, map[string]string, *agentProxy, error) {
+	var volumes []string
+	env := make(map[string]string)
+	var proxy *agentProxy
+
+	switch cmd.SSH {
+	case "":
+	case SSHAgent:
+		if runtime.GOOS == "darwin" {
+			volumes = append(volumes, dockerDesktopAgentSocket+":"+agentContainerSocket)
+		} else {
+			sock := os.Getenv("SSH_AUTH_SOCK")
+			if sock == "" {
+				return nil, nil, nil, errors.New("ssh agent forwarding needs SSH_AUTH_SOCK to be set")
+			}
+			// docker would create a folder in place of a missing socket
+			if info, err := os.Stat(sock); err != nil || info.Mode()&os.ModeSocket == 0 {
+				return nil, nil, nil, errors.New("ssh agent socket not found: " + sock)
+			}
+
+			// The agent socket is only accessible by its owner
+			if !isRootUser(cmd.User) && strings.SplitN(cmd.User, ":", 2)[0] != strconv.Itoa(os.Getuid()) {
+				clic, err := getClicHome()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				proxy = &agentProxy{
+					Listen: filepath.Join(clic, "run", "ssh-"+container+".sock"),
+					Target: sock,
+				}
+				sock = proxy.Listen
+			}
+
+			volumes = append(volumes, sock+":"+agentContainerSocket)
+		}
+		env["SSH_AUTH_SOCK"] = agentContainerSocket
+	default:
+		return nil, nil, nil, errors.New("Unknown ssh option for " + cmd.Name + ": " + string(cmd.SSH))
+	}
+
+	knownHosts := expandHome("~/.ssh/known_hosts")
+	if info, err := os.Stat(knownHosts); cmd.KnownHosts && err == nil && !info.IsDir() {
+		// The system wide location works for any user in the container.
+		// Without the file there are no known hosts to give.
+		volumes = append(volumes, knownHosts+":/etc/ssh/ssh_known_hosts:ro")
+	}
+
+	return volumes, env, proxy, nil
+}
+
+// start Listens for the container and relays each connection to the host agent
+func (p *agentProxy) start() (func(), error) {
+	err := os.MkdirAll(filepath.Dir(p.Listen), 0700)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(p.Listen)
+
+	l, err := net.Listen("unix", p.Listen)
+	if err != nil {
+		return nil, err
+	}
+
+	// The folder keeps other host users out
+	err = os.Chmod(p.Listen, 0666)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.relay(conn)
+		}
+	}()
+
+	return func() {
+		l.Close()
+		os.Remove(p.Listen)
+	}, nil
+}
+
+func (p *agentProxy) relay(conn net.Conn) {
+	defer conn.Close()
+
+	agent, err := net.Dial("unix", p.Target)
+	if err != nil {
+		return
+	}
+	defer agent.Close()
+
+	go io.Copy(agent, conn)
+	io.Copy(conn, agent)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDetermineSSH(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("the agent comes from Docker Desktop")
+	}
+	defer withTempHome(t)()
+	home := os.Getenv("HOME")
+
+	old := os.Getenv("SSH_AUTH_SOCK")
+	defer os.Setenv("SSH_AUTH_SOCK", old)
+
+	// A missing socket or known_hosts is never mounted
+	os.Setenv("SSH_AUTH_SOCK", filepath.Join(home, "missing.sock"))
+	_, _, _, err := determineSSH(RepoCommand{Name: "git", SSH: SSHAgent, User: "root"}, "clic-git-1")
+	assertEqual(t, "ssh agent socket not found: "+filepath.Join(home, "missing.sock"), err.Error())
+
+	sock := filepath.Join(home, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	os.Setenv("SSH_AUTH_SOCK", sock)
+
+	volumes, _, proxy, err := determineSSH(RepoCommand{Name: "git", SSH: SSHAgent, User: "root", KnownHosts: true}, "clic-git-1")
+	assertEqual(t, nil, err)
+	assertEqual(t, 1, len(volumes))
+	assertEqual(t, sock+":"+agentContainerSocket, volumes[0])
+	assertEqual(t, true, proxy == nil)
+
+	// The image's own user may not be root, so it gets the proxy
+	os.MkdirAll(filepath.Join(home, ".ssh"), 0700)
+	ioutil.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), nil, 0600)
+	volumes, _, proxy, err = determineSSH(RepoCommand{Name: "git", SSH: SSHAgent, KnownHosts: true}, "clic-git-1")
+	assertEqual(t, nil, err)
+	assertEqual(t, 2, len(volumes))
+	assertEqual(t, proxy.Listen+":"+agentContainerSocket, volumes[0])
+	assertEqual(t, filepath.Join(home, ".ssh", "known_hosts")+":/etc/ssh/ssh_known_hosts:ro", volumes[1])
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Labels that tie a container to the clic process that started it
+const (
+	LabelPid     = "clic.pid"
+	LabelHost    = "clic.host"
+	LabelStarted = "clic.started"
+)
+
+const (
+	// ModeRun Regular foreground container
+	ModeRun = "run"
+
+	orphanCheckInterval = time.Minute
+)
+
+// Signals forwarded to the container of a supervised command
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// runContainerName Names the container after the command and the clic process.
+// When the final command is exec'd, docker keeps the same pid.
+func runContainerName(cmd RepoCommand, img string) string {
+	name := cmd.Name
+	if name == "" {
+		name = commandNameFromImage(img)
+	}
+	return fmt.Sprintf("clic-%s-%d", containerSafeName(name), os.Getpid())
+}
+
+func runContainerLabels(cmd RepoCommand, img string) map[string]string {
+	name := cmd.Name
+	if name == "" {
+		name = commandNameFromImage(img)
+	}
+	host, _ := os.Hostname()
+	labels := map[string]string{
+		LabelCommand: name,
+		LabelMode:    ModeRun,
+		LabelPid:     strconv.Itoa(os.Getpid()),
+		LabelHost:    host,
+	}
+	// Tells the clic process apart from a later one that got the same pid
+	if started, ok := processStartTime(os.Getpid()); ok {
+		labels[LabelStarted] = started
+	}
+	return labels
+}
+
+// superviseCommand Runs the command as a child and forwards signals to its
+// container. Unless it needs to read the terminal, the child is in its own
+// process group so that signals from the terminal reach the container exactly
+// once, through clic. Otherwise docker gets them from the terminal and clic
+// only waits for it to exit.
+func superviseCommand(c Command) error {
+	ownGroup := !isTerminal(os.Stdin)
+
+	p := exec.Command(c.Name, c.Args...)
+	p.Stdout = os.Stdout
+	p.Stderr = c.stderr()
+	var mappers []*pathMapper
+	if len(c.PathMap) > 0 {
+		mappers = []*pathMapper{
+			newPathMapper(os.Stdout, c.PathMap, c.Tty),
+			newPathMapper(c.stderr(), c.PathMap, c.Tty),
+		}
+		p.Stdout = mappers[0]
+		p.Stderr = mappers[1]
+	}
+	if c.Stdin {
+		p.Stdin = os.Stdin
+	}
+	p.SysProcAttr = superviseProcAttr(ownGroup)
+
+	closeEnvFile, err := attachEnvFile(p, c.EnvFile)
+	if err != nil {
+		return err
+	}
+	defer closeEnvFile()
+
+	// Caught in any case, so clic lives on to clean up after the container
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, forwardedSignals...)
+	defer signal.Stop(sigs)
+
+	// In its own process group, docker doesn't see the terminal resize,
+	// so pass it on for docker to resize the container's tty
+	winch := make(chan os.Signal, 1)
+	if c.Tty && ownGroup && len(resizeSignals) > 0 {
+		signal.Notify(winch, resizeSignals...)
+		defer signal.Stop(winch)
+	}
+
+	if err := p.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := p.Wait()
+		for _, m := range mappers {
+			m.Flush()
+		}
+		done <- err
+	}()
+
+	var expired <-chan time.Time
+	if c.Timeout > 0 {
+		timer := time.NewTimer(c.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	timedOut := false
+
+	for {
+		select {
+		case <-expired:
+			timedOut = true
+			exec.Command("docker", "stop", c.Container).Run()
+		case sig := <-sigs:
+			if !ownGroup && sig != syscall.SIGTERM {
+				// docker got the signal from the terminal itself
+				continue
+			}
+			num := strconv.Itoa(int(sig.(syscall.Signal)))
+			exec.Command("docker", "kill", "--signal", num, c.Container).Run()
+		case sig := <-winch:
+			p.Process.Signal(sig)
+		case err := <-done:
+			if timedOut {
+				return &timeoutError{Name: c.Container, Timeout: c.Timeout}
+			}
+			if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() >= 0 {
+				return err
+			}
+			if err != nil {
+				// docker itself died, make sure the container goes with it
+				removeContainer(c.Container)
+			}
+			return err
+		}
+	}
+}
+
+func removeContainer(name string) {
+	exec.Command("docker", "rm", "-f", name).Run()
+}
+
+// cleanupOrphans Removes containers whose clic process on this host is gone.
+// This costs a call to docker, so it is done at most once per interval.
+func cleanupOrphans() {
+	clic, err := getClicHome()
+	if err != nil {
+		return
+	}
+	marker := filepath.Join(clic, "orphans-checked")
+
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < orphanCheckInterval {
+		return
+	}
+	ioutil.WriteFile(marker, nil, 0600)
+
+	host, _ := os.Hostname()
+	format := fmt.Sprintf(`{{.Names}}\t{{.Label "%s"}}\t{{.Label "%s"}}`, LabelPid, LabelStarted)
+	out, err := exec.Command("docker", "ps", "-a",
+		"--filter", "label="+LabelPid,
+		"--filter", "label="+LabelHost+"="+host,
+		"--format", format).Output()
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 3 || !orphaned(parts[1], parts[2]) {
+			continue
+		}
+		removeContainer(parts[0])
+	}
+
+	// Networks of services, after their containers are gone
+	format = fmt.Sprintf(`{{.Name}}\t{{.Label "%s"}}\t{{.Label "%s"}}\t{{.Label "%s"}}`, LabelHost, LabelPid, LabelStarted)
+	out, err = exec.Command("docker", "network", "ls",
+		"--filter", "label="+LabelPid,
+		"--format", format).Output()
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 4 || parts[1] != host || !orphaned(parts[2], parts[3]) {
+			continue
+		}
+		exec.Command("docker", "network", "rm", parts[0]).Run()
+	}
+}
+
+// orphaned Whether the clic process with the pid and start time is gone,
+// also when its pid has since been taken by another process
+func orphaned(pidLabel, started string) bool {
+	pid, err := strconv.Atoi(pidLabel)
+	if err != nil || pid == os.Getpid() {
+		return false
+	}
+	if !processAlive(pid) {
+		return true
+	}
+	current, ok := processStartTime(pid)
+	return started != "" && ok && current != started
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func superviseProcAttr(ownGroup bool) *syscall.SysProcAttr {
+	// Take docker down, and with it the container, if clic is killed
+	return &syscall.SysProcAttr{Setpgid: ownGroup, Pdeathsig: syscall.SIGTERM}
+}
+
+// processStartTime When the process started, in clock ticks since boot
+func processStartTime(pid int) (string, bool) {
+	data, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return "", false
+	}
+	// The command name in parentheses may contain spaces
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 20 {
+		return "", false
+	}
+	return fields[19], true
+}
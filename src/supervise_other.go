@@ -0,0 +1,22 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func superviseProcAttr(ownGroup bool) *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: ownGroup}
+}
+
+// processStartTime When the process started, as ps shows it
+func processStartTime(pid int) (string, bool) {
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	started := strings.TrimSpace(string(out))
+	return started, err == nil && started != ""
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestOrphaned(t *testing.T) {
+	parent := os.Getppid()
+	started, ok := processStartTime(parent)
+	if !ok {
+		t.Skip("process start time not available")
+	}
+
+	assertEqual(t, false, orphaned(strconv.Itoa(os.Getpid()), "0"))
+	assertEqual(t, false, orphaned(strconv.Itoa(parent), started))
+	assertEqual(t, false, orphaned(strconv.Itoa(parent), ""))
+	// The pid was taken by another process since
+	assertEqual(t, true, orphaned(strconv.Itoa(parent), started+"0"))
+	assertEqual(t, false, orphaned("", ""))
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Whether the final command can replace the clic process
+const canExec = true
+
+// Signals for a change of the terminal size
+var resizeSignals = []os.Signal{syscall.SIGWINCH}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
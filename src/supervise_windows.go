@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Windows can't replace a process, so the final command runs as a child
+const canExec = false
+
+// Windows has no signal for a change of the console size
+var resizeSignals []os.Signal
+
+func superviseProcAttr(ownGroup bool) *syscall.SysProcAttr {
+	if !ownGroup {
+		return nil
+	}
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+const processQueryLimitedInformation = 0x1000
+
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	const stillActive = 259
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == stillActive
+}
+
+// processStartTime When the process was created
+func processStartTime(pid int) (string, bool) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", false
+	}
+	defer syscall.CloseHandle(h)
+
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return "", false
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10), true
+}
@@ -0,0 +1,27 @@
+package main
+
+import "os"
+
+type winsize struct {
+	Rows    uint16
+	Cols    uint16
+	Xpixels uint16
+	Ypixels uint16
+}
+
+func isCharDevice(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && (info.Mode()&os.ModeCharDevice) != 0
+}
+
+// getTermDim Returns the size of the terminal on stdout, or stdin
+func getTermDim() (width, height int, err error) {
+	ws, err := getWinsize(os.Stdout)
+	if err != nil {
+		ws, err = getWinsize(os.Stdin)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return int(ws.Cols), int(ws.Rows), nil
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func getWinsize(f *os.File) (*winsize, error) {
+	ws := &winsize{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return ws, nil
+}
+
+// isTerminal Only a terminal has a window size, unlike
+// other character devices such as /dev/null
+func isTerminal(f *os.File) bool {
+	_, err := getWinsize(f)
+	return err == nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procGetConsoleScreenBufferInfo = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleScreenBufferInfo")
+
+type consoleCoord struct {
+	X, Y int16
+}
+
+type consoleScreenBufferInfo struct {
+	Size              consoleCoord
+	CursorPosition    consoleCoord
+	Attributes        uint16
+	Window            struct{ Left, Top, Right, Bottom int16 }
+	MaximumWindowSize consoleCoord
+}
+
+func getWinsize(f *os.File) (*winsize, error) {
+	var info consoleScreenBufferInfo
+	r, _, err := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return nil, err
+	}
+	return &winsize{
+		Rows: uint16(info.Window.Bottom - info.Window.Top + 1),
+		Cols: uint16(info.Window.Right - info.Window.Left + 1),
+	}, nil
+}
+
+// isTerminal Only a console has a console mode, unlike
+// other character devices such as NUL
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}